@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Exit codes returned by the CLI.
+const (
+	// exitOK is returned on success.
+	exitOK = 0
+
+	// exitError is returned on any error.
+	exitError = 1
+
+	// exitChanged is returned by --check when the generated output differs from the existing file.
+	exitChanged = 2
+)
+
+// checkOutput compares data with the contents of the file at path. If they differ, the difference
+// is written to w and false is returned.
+func checkOutput(w io.Writer, path string, data []byte) (bool, error) {
+	existing, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read existing policy file %v: %w", path, err)
+	}
+	if bytes.Equal(existing, data) {
+		return true, nil
+	}
+	fmt.Fprintf(w, "--- %v\n+++ generated\n", path)
+	writeDiff(w, string(existing), string(data))
+	return false, nil
+}
+
+// writeDiff writes the sources and lines of expected that are missing from got, prefixed with "-",
+// and those in got that are missing from expected, prefixed with "+".
+//
+// Each line is split on whitespace, so a change to a single source in a long script-src value is
+// shown as just that source.
+func writeDiff(w io.Writer, expected, got string) {
+	expectedLines := strings.Split(strings.TrimRight(expected, "\n"), "\n")
+	gotLines := strings.Split(strings.TrimRight(got, "\n"), "\n")
+	if len(expectedLines) == len(gotLines) {
+		for i := range expectedLines {
+			if expectedLines[i] != gotLines[i] {
+				diffFields(w, strings.Fields(expectedLines[i]), strings.Fields(gotLines[i]))
+			}
+		}
+		return
+	}
+	diffFields(w, expectedLines, gotLines)
+}
+
+// diffFields writes the values of expected missing from got prefixed with "-", and then the values
+// of got missing from expected prefixed with "+". Ordering changes are written as both.
+func diffFields(w io.Writer, expected, got []string) {
+	changed := false
+	for _, value := range expected {
+		if !slices.Contains(got, value) {
+			fmt.Fprintln(w, "-", value)
+			changed = true
+		}
+	}
+	for _, value := range got {
+		if !slices.Contains(expected, value) {
+			fmt.Fprintln(w, "+", value)
+			changed = true
+		}
+	}
+	if !changed {
+		fmt.Fprintln(w, "-", strings.Join(expected, " "))
+		fmt.Fprintln(w, "+", strings.Join(got, " "))
+	}
+}
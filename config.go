@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// defaultConfigFile is the config file loaded from the working directory, if it exists and no
+// --config is given.
+const defaultConfigFile = ".scriptsrcrc"
+
+// config holds CLI options loaded from a JSON config file. Command line flags override these.
+type config struct {
+	// HashAlgorithm is either "sha256" or "sha512".
+	HashAlgorithm string `json:"hashAlgorithm"`
+
+	// TemplateFile is equivalent to --csp-template-file.
+	TemplateFile string `json:"templateFile"`
+
+	// TemplateString is equivalent to --csp-template-string.
+	TemplateString string `json:"templateString"`
+
+	// TemplateName is equivalent to --template-name.
+	TemplateName string `json:"templateName"`
+
+	// Format is equivalent to --format.
+	Format string `json:"format"`
+
+	// SelfOrigin is equivalent to --self-origin.
+	SelfOrigin string `json:"selfOrigin"`
+
+	// ExcludeHosts are equivalent to --exclude-host.
+	ExcludeHosts []string `json:"excludeHosts"`
+
+	// ExtraSources are equivalent to --extra-source.
+	ExtraSources []string `json:"extraSources"`
+}
+
+// loadConfig loads the config from path. If path is empty, defaultConfigFile is loaded if it
+// exists, otherwise an empty config is returned.
+func loadConfig(path string) (config, error) {
+	var cfg config
+	explicit := path != ""
+	if !explicit {
+		path = defaultConfigFile
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, fs.ErrNotExist) {
+			return cfg, nil
+		}
+		return cfg, fmt.Errorf("failed to read config file: %w", err)
+	}
+	err = json.Unmarshal(data, &cfg)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to parse config file %v: %w", path, err)
+	}
+	return cfg, nil
+}
@@ -1,47 +1,287 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/JOT85/script-src-generator/scriptsrc"
+	"github.com/JOT85/script-src-generator/scriptsrchttp"
 )
 
 func exitWithError(msg ...any) {
 	fmt.Fprintln(os.Stderr, msg...)
-	os.Exit(1)
+	os.Exit(exitError)
+}
+
+// stdinPath is the path used for the HTML document read from stdin with --stdin-html.
+const stdinPath = "-"
+
+// addFromPath adds the sources from the HTML file at path to scriptSrc, or if path is an http or
+// https URL, from the HTML document it serves, or if path is stdinPath, from stdin.
+func addFromPath(scriptSrc *scriptsrc.ScriptSrc, path string) error {
+	if path == stdinPath {
+		return scriptSrc.AddFromHTMLReader(os.Stdin, true)
+	}
+	if strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "http://") {
+		return scriptsrchttp.AddFromURL(scriptSrc, path, true)
+	}
+	return scriptSrc.AddFromHTMLFile(path, true)
+}
+
+// expandDirs replaces each directory in paths with the HTML files found within it, recursively.
+// Other paths, such as files and URLs, are kept as they are.
+func expandDirs(paths []string) ([]string, error) {
+	expanded := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "http://") {
+			expanded = append(expanded, path)
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil || !info.IsDir() {
+			// Errors are reported when the file is read.
+			expanded = append(expanded, path)
+			continue
+		}
+		files, err := scriptsrc.FindHTMLFiles(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search directory %v: %w", path, err)
+		}
+		expanded = append(expanded, files...)
+	}
+	return expanded, nil
+}
+
+// addFromManifest adds the scripts in the manifest file at path to scriptSrc.
+func addFromManifest(scriptSrc *scriptsrc.ScriptSrc, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open manifest %v: %w", path, err)
+	}
+	defer f.Close()
+	err = scriptSrc.AddFromManifest(f, nil)
+	if err != nil {
+		return fmt.Errorf("failed to add scripts from manifest %v: %w", path, err)
+	}
+	return nil
 }
 
 func main() {
 	verbose := true
+	showContributions := false
+	report := false
 	cspTemplateFile := ""
 	cspTemplateString := ""
+	templateName := ""
 	hashAlgorithm := scriptsrc.Sha512
 	hashAlgorithmSet := false
+	outputFormat := scriptsrc.OutputPlain
+	outputFormatSet := false
+	selfOrigin := ""
+	var excludeHosts []string
+	var extraSources []string
+	configFile := ""
+	maxBytes := 0
+	outputFile := ""
+	checkFile := ""
+	hashStdin := false
+	strict := false
+	includeConnectionHints := false
+	collapseSubdomains := false
+	perFile := false
+	selfMode := scriptsrc.SelfAuto
+	targetLevel := scriptsrc.CSPLevelAny
+	integrityHashes := false
+	stdinHTML := false
+	eventHandlersOnly := false
+	reportSample := false
+	errorOnInsecure := false
+	noHosts := false
+	var manifests []string
+	var alsoWrites []alsoWrite
 
 	args := os.Args[1:]
 argParser:
 	for len(args) > 0 {
 		switch args[0] {
 		case "--help", "-h":
-			fmt.Println("Usage: " + os.Args[0] + " [--quiet] [--sha256 | --sha512] [--csp-template-file template-file | --csp-template-string template-string] <html file>...")
+			fmt.Println("Usage: " + os.Args[0] + " [--version] [--hash-stdin | --stdin-html] [--config config-file] [--quiet] [--show-contributions] [--report] [--sha256 | --sha512] [--self-origin origin] [--self auto|always|never] [--target-level any|2|3] [--exclude-host host]... [--collapse-subdomains] [--extra-source source]... [--manifest manifest-file]... [--include-connection-hints] [--integrity-hashes] [--error-on-insecure] [--no-hosts] [--report-sample] [--max-bytes n] [--strict] [--format plain|nginx|json | --csp-template-file template-file | --csp-template-string template-string] [--template-name name] [--per-file] [--event-handlers-only] [--output output-file | --check existing-policy-file] [--also-write format:path]... <html file, directory or url>...")
 			fmt.Println(`
-  --quiet stops outputting the files being processed to stderr
+  Each argument is either a path to an HTML file, a directory to recursively
+  search for .html, .htm, .html.gz and .htm.gz files, or an http:// or
+  https:// URL to fetch an HTML document from. Fetched documents must be
+  trusted!
+
+  --version outputs the version of this tool, and the Go version it was built
+    with, and exits
+
+  --hash-stdin reads the content of a single inline script from stdin, and
+    outputs its hash source, such as 'sha512-...', instead of processing any
+    HTML. The content is hashed exactly, including any trailing newline, so use
+    printf rather than echo, for example:
+    printf 'console.log(1)' | script-src-generator --sha256 --hash-stdin
+
+  --stdin-html reads a single HTML document from stdin, instead of from files
+    or URLs, for example:
+    cat page.html | script-src-generator --stdin-html
+
+  --config specifies a JSON file to load options from. If not given,
+    .scriptsrcrc is loaded from the working directory, if it exists. Options
+    given on the command line override the config file. For example:
+    {
+      "hashAlgorithm": "sha256",
+      "templateFile": "csp.tmpl",
+      "templateString": "",
+      "templateName": "",
+      "format": "plain",
+      "selfOrigin": "https://example.com",
+      "excludeHosts": ["https://example.com"],
+      "extraSources": ["https://www.google-analytics.com"]
+    }
+
+  --quiet stops outputting the files being processed, warnings about likely
+    mistakes in them, which files had no scripts, and recommendations for
+    improving the policy, to stderr
+
+  --show-contributions outputs, to stderr, the sources each file adds (+) and
+    the sources it uses that were already added by previous files (=)
+
+  --report outputs, to stderr, the final policy with its sources grouped by
+    type, and the file each hash was first found in, for reviewing
 
   --sha256 or --sha512 specifies the hashing algorithm to use for inline
     scripts. This currently defaults sha512 but is subject to change.
 
+  --self-origin specifies the origin the HTML is served from, such as
+    https://example.com. Hosts that are the same as this origin are replaced
+    with 'self'.
+
+  --self specifies whether 'self' is included:
+    - auto (the default) includes 'self' if any scripts are loaded from the
+      same origin, such as by a relative src
+    - always includes 'self', even if no scripts are loaded from it
+    - never omits 'self', even if scripts are loaded from it
+
+  --target-level specifies the CSP level of the browsers the policy is for:
+    - any (the default) targets every current browser
+    - 2 also warns about CSP Level 3 features, such as 'strict-dynamic' and
+      hashes of event handlers, which browsers only supporting CSP Level 2
+      ignore
+    - 3 only targets browsers supporting CSP Level 3
+
+  --exclude-host removes a host from the output, and may be given multiple
+    times
+
+  --collapse-subdomains replaces hosts that are subdomains of the same domain,
+    such as https://a.example.com and https://b.example.com, with a wildcard,
+    such as https://*.example.com. This allows scripts from any subdomain, so
+    only use it if every subdomain is trusted.
+
+  --extra-source adds a source that is always included, such as the host of a
+    script that is loaded dynamically, like https://www.google-analytics.com,
+    or a keyword like 'unsafe-eval'. It may be given multiple times.
+
+  --manifest adds the scripts listed in a JSON build manifest, such as one
+    written by Vite or webpack-assets-manifest, for scripts that are loaded
+    dynamically or by templated paths. Each value is either the script's URL,
+    or an object with the URL in a "file" or "src" field, and an optional
+    "integrity". It may be given multiple times.
+
+  --include-connection-hints adds the hosts of <link rel="preconnect"> and
+    <link rel="dns-prefetch"> elements, which often hint at hosts scripts are
+    loaded from dynamically. Links that fetch scripts, such as
+    <link rel="modulepreload">, are always included.
+
+  --integrity-hashes adds the hashes in the integrity attribute of external
+    scripts, when 'strict-dynamic' is added with --extra-source. Browsers
+    ignore hosts under 'strict-dynamic', but allow external scripts whose
+    integrity matches a hash in the policy.
+
+  --error-on-insecure fails if a script is loaded over http, instead of
+    ignoring it with a warning, since the policy can't allow it securely. Links
+    that preload scripts over http always fail.
+
+  --report-sample adds 'report-sample', so CSP violation reports include the
+    start of each blocked script. It only affects reports, sent when the policy
+    also has a report-uri or report-to directive, not which scripts run.
+
+  --no-hosts fails if any script, or link that fetches a script, is loaded
+    from another host, for pages that should only use same-origin or hashed
+    scripts. Hosts added with --extra-source or --manifest are still allowed.
+
+  --max-bytes fails if the script-src directive, including "script-src ", is
+    larger than the given number of bytes, printing how much replacing hashes
+    with a nonce, or 'strict-dynamic', would shrink it
+
+  --strict fails if the policy can't allow every script found without
+    'unsafe-inline', such as when event handlers are found, but 'unsafe-hashes'
+    isn't added with --extra-source. It also makes unrecognized extra sources,
+    such as the typo 'unsafe_eval', errors instead of warnings.
+
+  --format specifies the output format, instead of a template:
+    - plain (the default) outputs just the value of the script-src directive
+    - nginx outputs an nginx add_header directive, with comments listing the
+      files that contributed sources, and the file each inline script hash was
+      first found in
+    - json outputs a JSON object with the script-src value and each source,
+      including the files that contributed sources, and the file each inline
+      script hash was first found in
+
   --csp-template-file or --csp-template-string specifies an optional output
     template. This file will be parsed as a text template (see
-    https://pkg.go.dev/text/template) and executed to stdout.
+    https://pkg.go.dev/text/template) and executed to stdout. Templates may
+    contain comments, like {{/* comment */}}, which don't appear in the output.
+    If --csp-template-file is a directory, every file in it is parsed as a set
+    of templates, and --template-name must be given.
+
+  --template-name selects which template to execute from the set, by its file
+    name or the name of a template it defines with {{ define "name" }}
+
+  --per-file outputs a JSON object mapping each file to the script-src value
+    required by it alone, instead of one policy for all the files, for serving
+    a separate policy for each page. Extra sources are added to every file.
+
+  --event-handlers-only outputs only the hashes of event handlers, such as
+    onclick attributes, instead of the policy, to help find the event handlers
+    to move into scripts. The nginx and json formats include the file each was
+    first found in.
+
+  --output writes the output to the given file instead of stdout. The file is
+    replaced atomically, and left untouched if there are any errors.
+
+  --also-write writes the policy in another format, such as nginx:csp.conf, to
+    the given file, as well as the main output, without parsing the HTML again.
+    It may be given multiple times. No output is replaced unless every output
+    could be formatted and written, and if replacing one fails, those already
+    replaced are restored.
+
+  --check compares the output with the given existing file, instead of
+    outputting it. If they differ, the difference is printed to stderr and the
+    exit status is 2, so it can be used to check a policy is up to date in CI.
+    The exit status is 0 if they match, and 1 on any other error.
 
   The template is executed with the following fields available:
   - {{ .ScriptSrc }} the value of the script-src CSP, for example 
     "'self' 'sha512-....'  https://example.com".
     The struct formats as a string by default, but does have other fields, see
     https://pkg.go.dev/github.com/JOT85/script-src-generator/scriptsrc#ScriptSrc
+  - {{ .Files }} the files and URLs processed, in the order they were given
+  - {{ .GeneratedAt }} the time the output was generated, see
+    https://pkg.go.dev/time#Time, for example {{ .GeneratedAt.Format "2006-01-02" }}
+  - {{ .Version }} the version of this tool
+
+  For example, a template can start with a comment for a generated file:
+    # Generated by script-src-generator {{ .Version }} from:
+    {{ range .Files }}#   {{ . }}
+    {{ end }}
 
 For example:
 
@@ -51,9 +291,32 @@ For example:
 Will generate a content security policy for the files in /web/root.`)
 			return
 
+		case "--version":
+			fmt.Println(versionString())
+			return
+
+		case "--hash-stdin":
+			hashStdin = true
+
+		case "--stdin-html":
+			stdinHTML = true
+
+		case "--config":
+			args = args[1:]
+			if len(args) == 0 {
+				exitWithError("--config expected a config filepath")
+			}
+			configFile = args[0]
+
 		case "--quiet":
 			verbose = false
 
+		case "--show-contributions":
+			showContributions = true
+
+		case "--report":
+			report = true
+
 		case "--sha512":
 			if hashAlgorithmSet && hashAlgorithm != scriptsrc.Sha512 {
 				exitWithError("You must specify only one hash algorithm")
@@ -75,6 +338,138 @@ Will generate a content security policy for the files in /web/root.`)
 			}
 			cspTemplateFile = args[0]
 
+		case "--template-name":
+			args = args[1:]
+			if len(args) == 0 {
+				exitWithError("--template-name expected a template name")
+			}
+			templateName = args[0]
+
+		case "--self-origin":
+			args = args[1:]
+			if len(args) == 0 {
+				exitWithError("--self-origin expected an origin")
+			}
+			selfOrigin = args[0]
+
+		case "--self":
+			args = args[1:]
+			if len(args) == 0 {
+				exitWithError("--self expected auto, always or never")
+			}
+			mode, err := scriptsrc.ParseSelfMode(args[0])
+			if err != nil {
+				exitWithError(err)
+			}
+			selfMode = mode
+
+		case "--target-level":
+			args = args[1:]
+			if len(args) == 0 {
+				exitWithError("--target-level expected any, 2 or 3")
+			}
+			level, err := scriptsrc.ParseCSPLevel(args[0])
+			if err != nil {
+				exitWithError(err)
+			}
+			targetLevel = level
+
+		case "--exclude-host":
+			args = args[1:]
+			if len(args) == 0 {
+				exitWithError("--exclude-host expected a host")
+			}
+			excludeHosts = append(excludeHosts, args[0])
+
+		case "--collapse-subdomains":
+			collapseSubdomains = true
+
+		case "--extra-source":
+			args = args[1:]
+			if len(args) == 0 {
+				exitWithError("--extra-source expected a source")
+			}
+			extraSources = append(extraSources, args[0])
+
+		case "--manifest":
+			args = args[1:]
+			if len(args) == 0 {
+				exitWithError("--manifest expected a manifest file")
+			}
+			manifests = append(manifests, args[0])
+
+		case "--include-connection-hints":
+			includeConnectionHints = true
+
+		case "--integrity-hashes":
+			integrityHashes = true
+
+		case "--error-on-insecure":
+			errorOnInsecure = true
+
+		case "--no-hosts":
+			noHosts = true
+
+		case "--max-bytes":
+			args = args[1:]
+			if len(args) == 0 {
+				exitWithError("--max-bytes expected a number of bytes")
+			}
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n <= 0 {
+				exitWithError("--max-bytes expected a positive number of bytes, got", args[0])
+			}
+			maxBytes = n
+
+		case "--strict":
+			strict = true
+
+		case "--per-file":
+			perFile = true
+
+		case "--report-sample":
+			reportSample = true
+
+		case "--event-handlers-only":
+			eventHandlersOnly = true
+
+		case "--output":
+			args = args[1:]
+			if len(args) == 0 {
+				exitWithError("--output expected an output filepath")
+			}
+			outputFile = args[0]
+
+		case "--check":
+			args = args[1:]
+			if len(args) == 0 {
+				exitWithError("--check expected an existing policy filepath")
+			}
+			checkFile = args[0]
+
+		case "--format":
+			args = args[1:]
+			if len(args) == 0 {
+				exitWithError("--format expected an output format")
+			}
+			format, err := scriptsrc.ParseOutputFormat(args[0])
+			if err != nil {
+				exitWithError(err)
+			}
+			outputFormat = format
+			outputFormatSet = true
+
+		case "--also-write":
+			args = args[1:]
+			if len(args) == 0 {
+				exitWithError("--also-write expected format:path")
+			}
+			also, err := parseAlsoWrite(args[0])
+			if err != nil {
+				exitWithError("--also-write", err)
+			}
+			alsoWrites = append(alsoWrites, also)
+
 		case "--csp-template-string":
 			args = args[1:]
 			if len(args) == 0 {
@@ -91,28 +486,238 @@ Will generate a content security policy for the files in /web/root.`)
 		args = args[1:]
 	}
 
+	cfg, err := loadConfig(configFile)
+	if err != nil {
+		exitWithError(err)
+	}
+	if !hashAlgorithmSet {
+		switch cfg.HashAlgorithm {
+		case "", "sha512":
+		case "sha256":
+			hashAlgorithm = scriptsrc.Sha256
+		default:
+			exitWithError("Unknown hash algorithm in config file:", cfg.HashAlgorithm)
+		}
+	}
+	if cspTemplateFile == "" && cspTemplateString == "" && !outputFormatSet {
+		cspTemplateFile = cfg.TemplateFile
+		cspTemplateString = cfg.TemplateString
+		if templateName == "" {
+			templateName = cfg.TemplateName
+		}
+		if cfg.Format != "" {
+			outputFormat, err = scriptsrc.ParseOutputFormat(cfg.Format)
+			if err != nil {
+				exitWithError(err)
+			}
+			outputFormatSet = true
+		}
+	}
+	if selfOrigin == "" {
+		selfOrigin = cfg.SelfOrigin
+	}
+	if excludeHosts == nil {
+		excludeHosts = cfg.ExcludeHosts
+	}
+	if extraSources == nil {
+		extraSources = cfg.ExtraSources
+	}
+
+	if hashStdin {
+		if len(args) > 0 {
+			exitWithError("You may not specify files with --hash-stdin")
+		}
+		content, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			exitWithError("Failed to read stdin:", err)
+		}
+		hash, err := scriptsrc.HashInline(string(content), hashAlgorithm)
+		if err != nil {
+			exitWithError(err)
+		}
+		fmt.Printf("'%v'\n", hash)
+		return
+	}
+
+	if stdinHTML {
+		if len(args) > 0 {
+			exitWithError("You may not specify files with --stdin-html")
+		}
+		args = []string{stdinPath}
+	} else {
+		var err error
+		args, err = expandDirs(args)
+		if err != nil {
+			exitWithError(err)
+		}
+	}
+
+	extras := scriptsrc.ScriptSrc{
+		StrictSources:   strict,
+		ReportSample:    reportSample,
+		IntegrityHashes: integrityHashes,
+		ErrorOnInsecure: errorOnInsecure,
+	}
+	for _, src := range extraSources {
+		err := extras.AddSource(src)
+		if err != nil {
+			exitWithError("Invalid extra source:", err)
+		}
+	}
+	for _, path := range manifests {
+		err := addFromManifest(&extras, path)
+		if err != nil {
+			exitWithError(err)
+		}
+	}
+	if verbose {
+		for _, warning := range extras.Warnings {
+			fmt.Fprintln(os.Stderr, "Warning:", warning)
+		}
+	}
+	extras.Warnings = nil
 	scriptSrc := scriptsrc.ScriptSrc{
 		DefaultHashAlgorithm: hashAlgorithm,
+		MaxBytes:             maxBytes,
+	}
+	scriptSrc.Merge(&extras)
+	var perFilePolicies map[string]*scriptsrc.ScriptSrc
+	if perFile {
+		perFilePolicies = make(map[string]*scriptsrc.ScriptSrc, len(args))
 	}
 	errored := false
+	var scriptFree []string
 	for _, path := range args {
 		if verbose {
-			fmt.Fprintln(os.Stderr, ">", path)
+			if path == stdinPath {
+				fmt.Fprintln(os.Stderr, "> reading HTML from stdin")
+			} else {
+				fmt.Fprintln(os.Stderr, ">", path)
+			}
 		}
-		err := scriptSrc.AddFromHTMLFile(path, true)
+		contribution := scriptsrc.New(
+			scriptsrc.WithHashAlgorithm(hashAlgorithm),
+			scriptsrc.WithIncludeConnectionHints(includeConnectionHints),
+			scriptsrc.WithStrictDynamic(extras.StrictDynamic),
+			scriptsrc.WithIntegrityHashes(integrityHashes),
+			scriptsrc.WithErrorOnInsecure(errorOnInsecure),
+			scriptsrc.WithNoHosts(noHosts),
+		)
+		contribution.PreScan = true
+		err := addFromPath(contribution, path)
 		if err != nil {
 			errored = true
 			fmt.Fprintln(os.Stderr, err)
+			continue
+		}
+		if verbose {
+			for _, warning := range contribution.Warnings {
+				fmt.Fprintln(os.Stderr, "  warning:", warning)
+			}
+			if contribution.IsEmpty() {
+				fmt.Fprintln(os.Stderr, "  no scripts found")
+			}
+		}
+		if contribution.IsEmpty() {
+			scriptFree = append(scriptFree, path)
 		}
+		if showContributions {
+			existing := scriptSrc.Sources()
+			for _, src := range contribution.Sources() {
+				if slices.Contains(existing, src) {
+					fmt.Fprintln(os.Stderr, "  =", src)
+				} else {
+					fmt.Fprintln(os.Stderr, "  +", src)
+				}
+			}
+		}
+		contribution.Labels = make(map[string]string, len(contribution.Hashes))
+		for _, hash := range contribution.Hashes {
+			contribution.Labels[hash] = path
+		}
+		if !contribution.IsEmpty() && path != stdinPath {
+			contribution.Files = []string{path}
+		}
+		if perFile {
+			policy := scriptsrc.New(scriptsrc.WithMaxBytes(maxBytes))
+			policy.Merge(contribution)
+			policy.Merge(&extras)
+			perFilePolicies[path] = policy
+		}
+		scriptSrc.Merge(contribution)
 	}
 	if errored {
-		os.Exit(1)
+		os.Exit(exitError)
+	}
+	if verbose && len(scriptSrc.InsecureHosts) > 0 {
+		fmt.Fprintf(os.Stderr, "warning: scripts loaded over http were ignored, from: %v\n", strings.Join(scriptSrc.InsecureHosts, ", "))
+	}
+	if verbose && len(scriptFree) > 0 {
+		fmt.Fprintf(os.Stderr, "%v of %v files had no scripts: %v\n", len(scriptFree), len(args), strings.Join(scriptFree, ", "))
+	}
+
+	// finish applies the options that change the collected sources, and checks the result.
+	finish := func(scriptSrc *scriptsrc.ScriptSrc) error {
+		scriptSrc.SelfMode = selfMode
+		scriptSrc.TargetLevel = targetLevel
+		if selfOrigin != "" {
+			err := scriptSrc.CollapseSelfOrigin(selfOrigin)
+			if err != nil {
+				return err
+			}
+		}
+		scriptSrc.Hosts = slices.DeleteFunc(scriptSrc.Hosts, func(host string) bool {
+			return slices.Contains(excludeHosts, host)
+		})
+		if collapseSubdomains {
+			scriptSrc.CollapseSubdomains()
+		}
+
+		err := scriptSrc.CheckSize()
+		if err != nil {
+			for _, advice := range scriptSrc.SizeAdvice() {
+				fmt.Fprintln(os.Stderr, "advice:", advice)
+			}
+			return err
+		}
+		if strict {
+			return scriptSrc.CheckStrict()
+		}
+		return nil
+	}
+	if perFile {
+		for path, policy := range perFilePolicies {
+			err := finish(policy)
+			if err != nil {
+				exitWithError(path+":", err)
+			}
+		}
+	} else {
+		err = finish(&scriptSrc)
+		if err != nil {
+			exitWithError(err)
+		}
+	}
+	if eventHandlersOnly {
+		scriptSrc = *scriptSrc.EventHandlersOnly()
+		for path, policy := range perFilePolicies {
+			perFilePolicies[path] = policy.EventHandlersOnly()
+		}
+		if verbose {
+			fmt.Fprintln(os.Stderr, len(scriptSrc.Hashes), "distinct event handlers found")
+		}
+	} else if verbose && !perFile {
+		for _, recommendation := range scriptSrc.Recommendations() {
+			fmt.Fprintln(os.Stderr, "Recommendation:", recommendation)
+		}
+	}
+	if report {
+		fmt.Fprint(os.Stderr, scriptSrc.Report())
 	}
 
 	var cspTemplate *template.Template
-	var err error
 	if cspTemplateFile != "" {
-		cspTemplate, err = template.ParseFiles(cspTemplateFile)
+		cspTemplate, err = parseTemplateFile(cspTemplateFile, templateName)
 		if err != nil {
 			exitWithError("Failed to parse CSP template from", cspTemplateFile, ":", err)
 		}
@@ -122,20 +727,109 @@ Will generate a content security policy for the files in /web/root.`)
 			exitWithError("You may only specify one of --csp-template-file and --csp-template-string")
 		}
 		cspTemplate, err = template.New("csp-template-string").Parse(cspTemplateString)
+		if err == nil {
+			cspTemplate, err = lookupTemplate(cspTemplate, templateName)
+		}
 		if err != nil {
 			exitWithError("Failed to parse CSP template:", err)
 		}
 	}
 
-	if cspTemplate != nil {
-		err = cspTemplate.Execute(
-			os.Stdout,
-			struct{ *scriptsrc.ScriptSrc }{&scriptSrc},
-		)
+	if cspTemplate == nil && templateName != "" {
+		exitWithError("--template-name requires a CSP template")
+	}
+	if cspTemplate != nil && outputFormatSet {
+		exitWithError("You may not specify both --format and a CSP template")
+	}
+	if perFile && (cspTemplate != nil || outputFormatSet) {
+		exitWithError("You may not specify --per-file with --format or a CSP template")
+	}
+	if len(alsoWrites) > 0 && (perFile || checkFile != "") {
+		exitWithError("You may not specify --also-write with --per-file or --check")
+	}
+
+	var output bytes.Buffer
+	if perFile {
+		values := make(map[string]string, len(perFilePolicies))
+		for path, policy := range perFilePolicies {
+			values[path] = policy.String()
+		}
+		encoded, err := json.MarshalIndent(values, "", "  ")
+		if err != nil {
+			exitWithError("Failed to format output:", err)
+		}
+		output.Write(append(encoded, '\n'))
+	} else if cspTemplate != nil {
+		scriptSrc.Seal()
+		err = cspTemplate.Execute(&output, templateData{
+			ScriptSrc:   &scriptSrc,
+			Files:       args,
+			GeneratedAt: time.Now(),
+			Version:     getVersion(),
+		})
 		if err != nil {
 			exitWithError("Failed to execute CSP template:", err)
 		}
 	} else {
-		fmt.Println(scriptSrc.String())
+		rendered, err := scriptSrc.Render(outputFormat)
+		if err != nil {
+			exitWithError("Failed to format output:", err)
+		}
+		output.WriteString(rendered + "\n")
+	}
+	if checkFile != "" {
+		if outputFile != "" {
+			exitWithError("You may not specify both --check and --output")
+		}
+		matches, err := checkOutput(os.Stderr, checkFile, output.Bytes())
+		if err != nil {
+			exitWithError(err)
+		}
+		if !matches {
+			os.Exit(exitChanged)
+		}
+		os.Exit(exitOK)
+	}
+
+	// Render and stage every output before replacing any of them, so none are changed if any of
+	// them fail.
+	alsoRendered := make([][]byte, len(alsoWrites))
+	for i, also := range alsoWrites {
+		rendered, err := scriptSrc.Render(also.format)
+		if err != nil {
+			exitWithError("Failed to format output:", err)
+		}
+		alsoRendered[i] = []byte(rendered + "\n")
+	}
+	var staged []*stagedFile
+	discardStaged := func() {
+		for _, file := range staged {
+			file.discard()
+		}
+	}
+	for i, also := range alsoWrites {
+		file, err := stageFile(also.path, alsoRendered[i])
+		if err != nil {
+			discardStaged()
+			exitWithError("Failed to write output to", also.path, ":", err)
+		}
+		staged = append(staged, file)
+	}
+	if outputFile != "" {
+		file, err := stageFile(outputFile, output.Bytes())
+		if err != nil {
+			discardStaged()
+			exitWithError("Failed to write output:", err)
+		}
+		staged = append(staged, file)
+	}
+	// Outputs already replaced are restored if replacing a later one fails.
+	if err := commitAll(staged); err != nil {
+		exitWithError("Failed to write output:", err)
+	}
+	if outputFile == "" {
+		if err := writeOutput("", output.Bytes()); err != nil {
+			exitWithError("Failed to write output:", err)
+		}
 	}
 }
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/JOT85/script-src-generator/scriptsrc"
+)
+
+// alsoWrite is an additional output, given by --also-write format:path.
+type alsoWrite struct {
+	format scriptsrc.OutputFormat
+	path   string
+}
+
+// parseAlsoWrite parses the format:path argument to --also-write.
+func parseAlsoWrite(arg string) (alsoWrite, error) {
+	formatName, path, ok := strings.Cut(arg, ":")
+	if !ok || path == "" {
+		return alsoWrite{}, fmt.Errorf("expected format:path, got %q", arg)
+	}
+	format, err := scriptsrc.ParseOutputFormat(formatName)
+	if err != nil {
+		return alsoWrite{}, err
+	}
+	return alsoWrite{format, path}, nil
+}
+
+// writeOutput writes data to the file at path, or to stdout if path is empty.
+func writeOutput(path string, data []byte) error {
+	if path == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// writeFileAtomic writes data to a temporary file in the same directory as path, and then renames
+// it to path, so path is never left partially written. If an error occurs, any existing file at
+// path is left untouched.
+//
+// The permissions of an existing file at path are preserved, otherwise the file is created with
+// 0644 permissions.
+func writeFileAtomic(path string, data []byte) error {
+	staged, err := stageFile(path, data)
+	if err != nil {
+		return err
+	}
+	return staged.commit()
+}
+
+// stagedFile is data written to a temporary file, ready to replace the file at path, so several
+// files can be written before any of them are replaced.
+type stagedFile struct {
+	path    string
+	tmpPath string
+}
+
+// stageFile writes data to a temporary file in the same directory as path, with the permissions
+// of an existing file at path, or 0644, ready to be renamed to path by commit.
+func stageFile(path string, data []byte) (*stagedFile, error) {
+	mode := fs.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return nil, err
+	}
+	tmpPath := f.Name()
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, mode)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+	return &stagedFile{path, tmpPath}, nil
+}
+
+// commit renames the temporary file to path.
+func (staged *stagedFile) commit() error {
+	err := os.Rename(staged.tmpPath, staged.path)
+	if err != nil {
+		staged.discard()
+	}
+	return err
+}
+
+// discard removes the temporary file, leaving path untouched.
+func (staged *stagedFile) discard() {
+	os.Remove(staged.tmpPath)
+}
+
+// commitAll commits each of files, in order. If a commit fails, the remaining files are discarded,
+// and the files already committed are rolled back, to their previous contents, or removed if they
+// didn't exist, so none of the files are replaced. Errors rolling back are returned too.
+func commitAll(files []*stagedFile) error {
+	type backup struct {
+		path     string
+		previous []byte
+		existed  bool
+	}
+	var committed []backup
+	for i, file := range files {
+		previous, err := os.ReadFile(file.path)
+		existed := err == nil
+		if err == nil || errors.Is(err, fs.ErrNotExist) {
+			err = file.commit()
+		} else {
+			file.discard()
+		}
+		if err == nil {
+			committed = append(committed, backup{file.path, previous, existed})
+			continue
+		}
+
+		for _, remaining := range files[i+1:] {
+			remaining.discard()
+		}
+		errs := []error{err}
+		for _, backup := range committed {
+			var rollbackErr error
+			if backup.existed {
+				rollbackErr = writeFileAtomic(backup.path, backup.previous)
+			} else {
+				rollbackErr = os.Remove(backup.path)
+			}
+			if rollbackErr != nil {
+				errs = append(errs, fmt.Errorf("failed to restore %v: %w", backup.path, rollbackErr))
+			}
+		}
+		return errors.Join(errs...)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCommitAllRollsBack(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "existing.txt")
+	created := filepath.Join(dir, "created.txt")
+	failing := filepath.Join(dir, "failing")
+	if err := os.WriteFile(existing, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var staged []*stagedFile
+	for _, path := range []string{existing, created, failing} {
+		file, err := stageFile(path, []byte("new"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		staged = append(staged, file)
+	}
+	// A file can't be renamed over a directory, so the last commit fails.
+	if err := os.Mkdir(failing, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := commitAll(staged); err == nil {
+		t.Fatal("expected an error committing over a directory")
+	}
+	if data, err := os.ReadFile(existing); err != nil || string(data) != "old" {
+		t.Errorf("expected %v to be restored, got %q, %v", existing, data, err)
+	}
+	if _, err := os.Stat(created); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected %v to be removed, got %v", created, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("expected only the existing file and directory to be left, got %v", entries)
+	}
+}
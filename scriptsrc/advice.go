@@ -0,0 +1,111 @@
+package scriptsrc
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Thresholds used by [ScriptSrc.Recommendations].
+const (
+	// ManyHashes is the number of hashes at which 'strict-dynamic' is recommended.
+	ManyHashes = 20
+
+	// ManyHosts is the number of hosts at which 'strict-dynamic' is recommended.
+	ManyHosts = 10
+)
+
+// plural formats n and noun, such as "1 host" or "2 hosts". Nouns ending in "h", such as "hash",
+// are pluralized with "es".
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	if strings.HasSuffix(noun, "h") {
+		return fmt.Sprintf("%v %ves", n, noun)
+	}
+	return fmt.Sprintf("%v %vs", n, noun)
+}
+
+// ignoredSources describes the sources browsers ignore under 'strict-dynamic', such as
+// "'self' and 2 hosts", only mentioning 'self' if self is set, and hosts and scheme sources if
+// there are any.
+func ignoredSources(self bool, hosts, schemes int) string {
+	var parts []string
+	if self {
+		parts = append(parts, "'self'")
+	}
+	if hosts > 0 {
+		parts = append(parts, plural(hosts, "host"))
+	}
+	if schemes > 0 {
+		parts = append(parts, plural(schemes, "scheme source"))
+	}
+	if len(parts) <= 1 {
+		return strings.Join(parts, "")
+	}
+	return strings.Join(parts[:len(parts)-1], ", ") + " and " + parts[len(parts)-1]
+}
+
+// recommendation is a rule used by Recommendations. If applies returns true, message is
+// recommended.
+type recommendation struct {
+	applies func(scriptSrc *ScriptSrc) bool
+	message func(scriptSrc *ScriptSrc) string
+}
+
+// recommendations are the rules checked by Recommendations, in the order they are reported.
+var recommendations = []recommendation{
+	{
+		applies: func(scriptSrc *ScriptSrc) bool {
+			return !scriptSrc.StrictDynamic && (len(scriptSrc.Hashes) >= ManyHashes || len(scriptSrc.Hosts) >= ManyHosts)
+		},
+		message: func(scriptSrc *ScriptSrc) string {
+			return fmt.Sprintf(
+				"consider 'strict-dynamic' - you have %v and %v",
+				plural(len(scriptSrc.Hashes), "script hash"), plural(len(scriptSrc.Hosts), "host"),
+			)
+		},
+	},
+	{
+		applies: func(scriptSrc *ScriptSrc) bool {
+			return scriptSrc.StrictDynamic && (scriptSrc.includesSelf() || len(scriptSrc.Hosts) > 0)
+		},
+		message: func(scriptSrc *ScriptSrc) string {
+			return fmt.Sprintf(
+				"browsers supporting 'strict-dynamic' ignore %v, so scripts they load must be allowed by a hash instead",
+				ignoredSources(scriptSrc.includesSelf(), len(scriptSrc.Hosts), 0),
+			)
+		},
+	},
+	{
+		applies: func(scriptSrc *ScriptSrc) bool {
+			return len(scriptSrc.Hashes) > 0 && slices.Contains(scriptSrc.Others, "'unsafe-inline'")
+		},
+		message: func(scriptSrc *ScriptSrc) string {
+			return "'unsafe-inline' is ignored by browsers supporting hashes, so only has an effect in very old browsers"
+		},
+	},
+	{
+		applies: func(scriptSrc *ScriptSrc) bool {
+			return scriptSrc.CheckSize() != nil
+		},
+		message: func(scriptSrc *ScriptSrc) string {
+			return scriptSrc.CheckSize().Error()
+		},
+	},
+}
+
+// Recommendations inspects scriptSrc, and returns human readable suggestions for improving it.
+//
+// An empty result means there is nothing to suggest.
+func (scriptSrc *ScriptSrc) Recommendations() []string {
+	var messages []string
+	for _, r := range recommendations {
+		if r.applies(scriptSrc) {
+			messages = append(messages, r.message(scriptSrc))
+		}
+	}
+	messages = append(messages, scriptSrc.SizeAdvice()...)
+	return append(messages, scriptSrc.LevelWarnings()...)
+}
@@ -0,0 +1,66 @@
+package scriptsrc
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestIgnoredSources(t *testing.T) {
+	tests := []struct {
+		self           bool
+		hosts, schemes int
+		expected       string
+	}{
+		{true, 0, 0, "'self'"},
+		{false, 1, 0, "1 host"},
+		{true, 2, 0, "'self' and 2 hosts"},
+		{true, 1, 1, "'self', 1 host and 1 scheme source"},
+		{false, 0, 3, "3 scheme sources"},
+	}
+	for _, test := range tests {
+		if got := ignoredSources(test.self, test.hosts, test.schemes); got != test.expected {
+			t.Errorf("expected %q, got %q", test.expected, got)
+		}
+	}
+	if got := plural(2, "hash"); got != "2 hashes" {
+		t.Errorf("expected 2 hashes, got %q", got)
+	}
+}
+
+func TestRecommendations(t *testing.T) {
+	manyHashes := &ScriptSrc{}
+	for i := 0; i < ManyHashes; i++ {
+		manyHashes.AddInline(fmt.Sprint(i))
+	}
+
+	tests := []struct {
+		scriptSrc *ScriptSrc
+		expected  []string
+	}{
+		{&ScriptSrc{Self: true, Hosts: []string{"https://example.com"}}, nil},
+		{manyHashes, []string{"consider 'strict-dynamic' - you have 20 script hashes and 0 hosts"}},
+		{
+			&ScriptSrc{StrictDynamic: true, Self: true, Hosts: []string{"https://example.com"}},
+			[]string{"browsers supporting 'strict-dynamic' ignore 'self' and 1 host, so scripts they load must be allowed by a hash instead"},
+		},
+		{
+			&ScriptSrc{StrictDynamic: true, Hosts: []string{"https://a.example.com", "https://b.example.com"}},
+			[]string{"browsers supporting 'strict-dynamic' ignore 2 hosts, so scripts they load must be allowed by a hash instead"},
+		},
+		{
+			&ScriptSrc{Hashes: []string{"sha256-ypeBEsobvcr6wjGzmiPcTaeG7/gUfE5yuYB3ha/uSLs="}, Others: []string{"'unsafe-inline'"}},
+			[]string{"'unsafe-inline' is ignored by browsers supporting hashes, so only has an effect in very old browsers"},
+		},
+		{
+			&ScriptSrc{Self: true, MaxBytes: 10},
+			[]string{"script-src directive too large: 17 bytes exceeds the limit of 10 bytes (with 0 hashes and 0 hosts), consider using nonces or 'strict-dynamic' instead of hashing every script"},
+		},
+	}
+	for _, test := range tests {
+		got := test.scriptSrc.Recommendations()
+		if !slices.Equal(got, test.expected) {
+			t.Errorf("mismatched recommendations for %v: expected %q, got %q", test.scriptSrc, test.expected, got)
+		}
+	}
+}
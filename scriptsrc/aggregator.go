@@ -0,0 +1,89 @@
+package scriptsrc
+
+import (
+	"slices"
+	"sort"
+)
+
+// Aggregator keeps the contribution of each HTML file to a combined ScriptSrc, so a single file can
+// be updated or removed without processing every file again, such as when a file watcher in a
+// development server sees a file change.
+//
+// The provenance of each source, which files require it, is kept, so a source is only removed when
+// no remaining file requires it.
+//
+// An Aggregator isn't safe for concurrent use.
+type Aggregator struct {
+	includeEventHandlers bool
+	opts                 []Option
+	files                map[string]*ScriptSrc
+}
+
+// NewAggregator returns an empty Aggregator, which applies opts to the ScriptSrc of each file, and
+// includes event handlers if includeEventHandlers is set.
+func NewAggregator(includeEventHandlers bool, opts ...Option) *Aggregator {
+	return &Aggregator{
+		includeEventHandlers: includeEventHandlers,
+		opts:                 opts,
+		files:                make(map[string]*ScriptSrc),
+	}
+}
+
+// UpdateFromHTMLFile processes the HTML file at path, replacing any previous contribution from it.
+//
+// If an error is returned, the previous contribution from path, if any, is kept. Use Remove for
+// files that have been deleted.
+func (aggregator *Aggregator) UpdateFromHTMLFile(path string) error {
+	contribution := New(aggregator.opts...)
+	err := contribution.AddFromHTMLFile(path, aggregator.includeEventHandlers)
+	if err != nil {
+		return err
+	}
+	contribution.Labels = make(map[string]string, len(contribution.Hashes))
+	for _, hash := range contribution.Hashes {
+		contribution.Labels[hash] = path
+	}
+	aggregator.files[path] = contribution
+	return nil
+}
+
+// Remove removes the contribution from the file at path, reporting whether there was one.
+func (aggregator *Aggregator) Remove(path string) bool {
+	_, ok := aggregator.files[path]
+	delete(aggregator.files, path)
+	return ok
+}
+
+// Files returns the path of every file with a contribution, in lexical order.
+func (aggregator *Aggregator) Files() []string {
+	paths := make([]string, 0, len(aggregator.files))
+	for path := range aggregator.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// FilesRequiring returns the path of every file that requires src, a source as returned by
+// ScriptSrc.Sources, such as 'self' or https://cdn.example.com, in lexical order.
+func (aggregator *Aggregator) FilesRequiring(src string) []string {
+	var paths []string
+	for _, path := range aggregator.Files() {
+		if slices.Contains(aggregator.files[path].Sources(), src) {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// ScriptSrc returns the script-src required by every file, merging their contributions in lexical
+// order of their paths. The label of each hash is the path of the first file that requires it.
+//
+// The result is a new ScriptSrc, with opts applied, so it can be changed freely.
+func (aggregator *Aggregator) ScriptSrc() *ScriptSrc {
+	scriptSrc := New(aggregator.opts...)
+	for _, path := range aggregator.Files() {
+		scriptSrc.Merge(aggregator.files[path])
+	}
+	return scriptSrc
+}
@@ -0,0 +1,62 @@
+package scriptsrc
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestAggregator(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	a := write("a.html", `<script src="https://cdn.example.com/a.js"></script><script>a</script>`)
+	b := write("b.html", `<script src="https://cdn.example.com/b.js"></script><script src="/b.js"></script>`)
+
+	aggregator := NewAggregator(false, WithHashAlgorithm(Sha256))
+	for _, path := range []string{a, b} {
+		if err := aggregator.UpdateFromHTMLFile(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := "'self' 'sha256-ypeBEsobvcr6wjGzmiPcTaeG7/gUfE5yuYB3ha/uSLs=' https://cdn.example.com"
+	scriptSrc := aggregator.ScriptSrc()
+	if got := scriptSrc.String(); got != expected {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	if label := scriptSrc.Labels["sha256-ypeBEsobvcr6wjGzmiPcTaeG7/gUfE5yuYB3ha/uSLs="]; label != a {
+		t.Errorf("expected the hash to be labelled with %v, got %v", a, label)
+	}
+	if files := aggregator.FilesRequiring("https://cdn.example.com"); !slices.Equal(files, []string{a, b}) {
+		t.Errorf("expected both files to require the host, got %v", files)
+	}
+
+	// The host is still required by b.html, so only the hash is removed.
+	write("a.html", `<script src="https://cdn.example.com/a.js"></script>`)
+	if err := aggregator.UpdateFromHTMLFile(a); err != nil {
+		t.Fatal(err)
+	}
+	if got := aggregator.ScriptSrc().String(); got != "'self' https://cdn.example.com" {
+		t.Errorf("expected the hash to be removed, got %v", got)
+	}
+
+	if !aggregator.Remove(b) || aggregator.Remove(b) {
+		t.Errorf("expected b.html to be removed once")
+	}
+	if got := aggregator.ScriptSrc().String(); got != "https://cdn.example.com" {
+		t.Errorf("expected 'self' to be removed with b.html, got %v", got)
+	}
+
+	if err := aggregator.UpdateFromHTMLFile(filepath.Join(dir, "missing.html")); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+	if files := aggregator.Files(); !slices.Equal(files, []string{a}) {
+		t.Errorf("expected only a.html to remain, got %v", files)
+	}
+}
@@ -0,0 +1,93 @@
+package scriptsrc
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"slices"
+	"strings"
+)
+
+// digestFuncs compute the digest of content for each algorithm allowed in CSP hash sources.
+var digestFuncs = map[string]func(content []byte) []byte{
+	"sha256": func(content []byte) []byte { sum := sha256.Sum256(content); return sum[:] },
+	"sha384": func(content []byte) []byte { sum := sha512.Sum384(content); return sum[:] },
+	"sha512": func(content []byte) []byte { sum := sha512.Sum512(content); return sum[:] },
+}
+
+// AllowsInline reports whether browsers would run an inline script with the given content under
+// scriptSrc, for asserting that a generated policy allows a script.
+//
+// The script is allowed if any of the Hashes, of any algorithm or encoding, matches content, or,
+// if there are no hashes or nonces and no 'strict-dynamic', if 'unsafe-inline' is in Others, as in
+// [the CSP matching algorithm]. Content is normalized first if NormalizeLineEndings is set, as it
+// is by AddInline. Nonces are never matched, since content alone can't have a nonce.
+//
+// For example, to check in a test that a generated policy allows a script a page needs:
+//
+//	if !scriptSrc.AllowsInline(analyticsSnippet) {
+//		t.Errorf("script-src %v blocks the analytics snippet", scriptSrc)
+//	}
+//
+// [the CSP matching algorithm]: https://www.w3.org/TR/CSP3/#match-element-to-source-list
+func (scriptSrc *ScriptSrc) AllowsInline(content string) bool {
+	if scriptSrc.NormalizeLineEndings {
+		content = normalizeLineEndings(content)
+	}
+	for _, hash := range scriptSrc.Hashes {
+		alg, digest, err := parseHashSource(hash)
+		if err == nil && slices.Equal(digestFuncs[alg]([]byte(content)), digest) {
+			return true
+		}
+	}
+	if scriptSrc.hasHashesOrNonces() || scriptSrc.StrictDynamic {
+		return false
+	}
+	return slices.ContainsFunc(scriptSrc.Others, func(src string) bool {
+		return strings.EqualFold(strings.TrimSpace(src), "'unsafe-inline'")
+	})
+}
+
+// AllowsHost reports whether browsers would load an external script from src, such as
+// https://cdn.example.com/lib.js, under scriptSrc, for asserting that a generated policy allows a
+// script. Relative srcs are allowed if 'self' is included.
+//
+// Under 'strict-dynamic', browsers ignore 'self' and host sources, and only load external scripts
+// that are hashed or loaded by trusted scripts, so this always returns false.
+//
+// Hosts are matched by scheme, host and port, including wildcards such as https://*.example.com,
+// and scheme sources in Others, such as https:. Paths in host sources aren't supported.
+//
+// As with [ScriptSrc.AllowsInline], this is mainly for tests:
+//
+//	if !scriptSrc.AllowsHost("https://challenges.cloudflare.com/turnstile/v0/api.js") {
+//		t.Errorf("script-src %v blocks Turnstile", scriptSrc)
+//	}
+func (scriptSrc *ScriptSrc) AllowsHost(src string) bool {
+	if scriptSrc.StrictDynamic {
+		return false
+	}
+	host, err := hostSource("script", src)
+	if err != nil {
+		return false
+	}
+	if host == "" {
+		return scriptSrc.includesSelf()
+	}
+	covering := make([]string, 0, len(scriptSrc.Hosts)+len(scriptSrc.Others))
+	for _, allowed := range scriptSrc.Hosts {
+		if !strings.Contains(allowed, "://") {
+			// Host sources without a scheme match the scheme of the page, which should be https.
+			allowed = "https://" + allowed
+		}
+		if strings.EqualFold(allowed, host) {
+			return true
+		}
+		covering = append(covering, allowed)
+	}
+	for _, other := range scriptSrc.Others {
+		if other = strings.TrimSpace(other); schemeSourcePattern.MatchString(other) {
+			covering = append(covering, other)
+		}
+	}
+	return hostCoveredBy(host, covering) != ""
+}
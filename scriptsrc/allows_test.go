@@ -0,0 +1,66 @@
+package scriptsrc
+
+import "testing"
+
+func TestAllowsInline(t *testing.T) {
+	hashed := New(WithHashAlgorithm(Sha256))
+	hashed.AddInline("a")
+	hashed.AddSource("'sha384-H8BRh8j48O9oYatfu5AZzq6A9RINhZO5H16dQZngK7T62em8MUt1FLm52t+eX6xO'")
+	urlSafe := ScriptSrc{Hashes: []string{"sha256-ypeBEsobvcr6wjGzmiPcTaeG7_gUfE5yuYB3ha_uSLs="}}
+	tests := []struct {
+		name      string
+		scriptSrc *ScriptSrc
+		content   string
+		expected  bool
+	}{
+		{"hashed", hashed, "a", true},
+		{"sha384", hashed, "alert('Hello, world.');", true},
+		{"unhashed", hashed, "b", false},
+		{"url-safe", &urlSafe, "a", true},
+		{"unsafe-inline", &ScriptSrc{Others: []string{"'unsafe-inline'"}}, "b", true},
+		{"unsafe-inline with hashes", &ScriptSrc{Hashes: hashed.Hashes, Others: []string{"'unsafe-inline'"}}, "b", false},
+		{"unsafe-inline with nonces", &ScriptSrc{Others: []string{"'unsafe-inline'", "'nonce-abc'"}}, "b", false},
+		{"unsafe-inline with strict-dynamic", &ScriptSrc{StrictDynamic: true, Others: []string{"'unsafe-inline'"}}, "b", false},
+		{"empty", &ScriptSrc{}, "a", false},
+	}
+	for _, test := range tests {
+		if got := test.scriptSrc.AllowsInline(test.content); got != test.expected {
+			t.Errorf("%v: expected %v, got %v", test.name, test.expected, got)
+		}
+	}
+}
+
+func TestAllowsHost(t *testing.T) {
+	scriptSrc := ScriptSrc{
+		Self:   true,
+		Hosts:  []string{"https://cdn.example.com", "https://*.example.org", "static.example.net"},
+		Others: []string{"wss:"},
+	}
+	tests := []struct {
+		src      string
+		expected bool
+	}{
+		{"/app.js", true},
+		{"https://cdn.example.com/lib.js", true},
+		{"//cdn.example.com/lib.js", true},
+		{"https://cdn.example.com:8443/lib.js", false},
+		{"https://a.example.org/lib.js", true},
+		{"https://example.org/lib.js", false},
+		{"https://static.example.net/lib.js", true},
+		{"https://other.example.com/lib.js", false},
+		{"http://cdn.example.com/lib.js", false},
+	}
+	for _, test := range tests {
+		if got := scriptSrc.AllowsHost(test.src); got != test.expected {
+			t.Errorf("%v: expected %v, got %v", test.src, test.expected, got)
+		}
+	}
+
+	scriptSrc.StrictDynamic = true
+	if scriptSrc.AllowsHost("https://cdn.example.com/lib.js") {
+		t.Errorf("expected hosts to be ignored under 'strict-dynamic'")
+	}
+	if (&ScriptSrc{}).AllowsHost("/app.js") {
+		t.Errorf("expected a relative src to need 'self'")
+	}
+}
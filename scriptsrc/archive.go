@@ -0,0 +1,65 @@
+package scriptsrc
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+)
+
+// AddFromTarGz calls scriptSrc.AddFromHTMLFile for every regular file in the gzip compressed tar
+// archive read from r, such as a CI artifact of a built site, with a .html, .htm, .html.gz or
+// .htm.gz extension, without extracting it.
+//
+// The archive is streamed, but each HTML file is read fully into memory before it's parsed, as
+// it is by AddFromHTMLFile, so memory use depends on the largest HTML file, not the archive.
+func (scriptSrc *ScriptSrc) AddFromTarGz(r io.Reader, includeEventHandlers bool) error {
+	if err := scriptSrc.checkSealed("AddFromTarGz"); err != nil {
+		return err
+	}
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("failed to decompress archive: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg || !isHTMLPath(header.Name) {
+			continue
+		}
+		data, err := readHTML(tr, header.Name)
+		if err != nil {
+			return err
+		}
+		err = scriptSrc.addFromHTMLData(header.Name, data, includeEventHandlers)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// ScriptSrcFromTarGz generates the script-src required to load any of the HTML files in the gzip
+// compressed tar archive at path. See [ScriptSrc.AddFromTarGz].
+//
+// The input files must be trusted HTML files! See the package documentation if you're unsure.
+func ScriptSrcFromTarGz(path string, includeEventHandlers bool) (*ScriptSrc, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive %v: %w", path, err)
+	}
+	defer f.Close()
+	scriptSrc := &ScriptSrc{}
+	err = scriptSrc.AddFromTarGz(f, includeEventHandlers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add from archive %v: %w", path, err)
+	}
+	return scriptSrc, nil
+}
@@ -0,0 +1,60 @@
+package scriptsrc
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScriptSrcFromTarGz(t *testing.T) {
+	files := []struct {
+		name string
+		data string
+	}{
+		{"site/index.html", `<script src="https://example.com/a.js"></script><script>console.log("a");</script>`},
+		{"site/sub/page.htm", `<button onclick="go()">Go</button>`},
+		{"site/ignored.txt", `<script src="https://ignored.example.com/a.js"></script>`},
+	}
+	path := filepath.Join(t.TempDir(), "site.tar.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	err = tw.WriteHeader(&tar.Header{Name: "site/", Typeflag: tar.TypeDir, Mode: 0755})
+	for _, file := range files {
+		if err != nil {
+			break
+		}
+		err = tw.WriteHeader(&tar.Header{Name: file.name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(file.data))})
+		if err == nil {
+			_, err = tw.Write([]byte(file.data))
+		}
+	}
+	for _, closer := range []interface{ Close() error }{tw, gz, f} {
+		if closeErr := closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scriptSrc, err := ScriptSrcFromTarGz(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := ScriptSrc{Hosts: []string{"https://example.com"}}
+	expected.AddInline(`console.log("a");`)
+	expected.AddInline("go()")
+	if scriptSrc.String() != expected.String() {
+		t.Errorf("expected %v, got %v", &expected, scriptSrc)
+	}
+
+	if _, err := ScriptSrcFromTarGz("./tests/index.html", false); err == nil {
+		t.Errorf("expected an error for a file that isn't an archive")
+	}
+}
@@ -0,0 +1,84 @@
+package scriptsrc
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// elementHandler handles the elements with a given name, which is compared case insensitively,
+// since names are only lower cased by the parser for HTML content, and not, for example, for
+// XML-style foreign content.
+//
+// The handler reports whether it handled the element completely, in which case its event handlers
+// and children aren't walked.
+type elementHandler[T any] struct {
+	name   string
+	handle func(t T, n *html.Node) (bool, error)
+}
+
+// lookupElementHandler returns the handler in handlers for the element n, or nil if there isn't
+// one, or n isn't an element.
+func lookupElementHandler[T any](handlers []elementHandler[T], n *html.Node) *elementHandler[T] {
+	if n.Type != html.ElementNode {
+		return nil
+	}
+	for i := range handlers {
+		if strings.EqualFold(n.Data, handlers[i].name) {
+			return &handlers[i]
+		}
+	}
+	return nil
+}
+
+// scriptSrcElements are the handlers for elements that require script-src sources, used by
+// [ScriptSrc.AddFromHTML].
+var scriptSrcElements = []elementHandler[*ScriptSrc]{
+	{"script", func(scriptSrc *ScriptSrc, n *html.Node) (bool, error) {
+		err := scriptSrc.addFromScript(n)
+		if err != nil {
+			return true, &scriptError{node: n, err: err}
+		}
+		return true, nil
+	}},
+	{"link", func(scriptSrc *ScriptSrc, n *html.Node) (bool, error) {
+		return false, scriptSrc.addFromLink(n)
+	}},
+}
+
+// srcAttribute identifies the attributes of an element that hold the URL of the resource it loads.
+type srcAttribute struct {
+	element string
+	isSrc   func(n *html.Node, attr html.Attribute) bool
+}
+
+// srcAttributes are the attributes, by element, that hold the URL of the resource the element
+// loads, such as the src of a script. Elements are compared as in elementHandler.
+var srcAttributes = []srcAttribute{
+	{"script", isScriptSrcAttr},
+	{"iframe", isSrcAttr},
+	{"frame", isSrcAttr},
+}
+
+// isSrcAttr reports whether attr is a src attribute.
+func isSrcAttr(n *html.Node, attr html.Attribute) bool {
+	return strings.EqualFold(attr.Key, "src")
+}
+
+// srcAttrs returns the attributes of the element n that hold the URL of the resource it loads,
+// according to srcAttributes, in the order they appear.
+func srcAttrs(n *html.Node) []html.Attribute {
+	for _, src := range srcAttributes {
+		if !strings.EqualFold(n.Data, src.element) {
+			continue
+		}
+		var attrs []html.Attribute
+		for _, attr := range n.Attr {
+			if src.isSrc(n, attr) {
+				attrs = append(attrs, attr)
+			}
+		}
+		return attrs
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package scriptsrc
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestSrcAttrs(t *testing.T) {
+	tests := map[string][]string{
+		`<script src="a.js" SRC="b.js"></script>`:                    {"a.js", "b.js"},
+		`<svg><script href="a.js" xlink:href="b.js"></script></svg>`: {"a.js"},
+		`<svg><script xlink:href="b.js"></script></svg>`:             {"b.js"},
+		`<iframe src="https://www.youtube.com/embed/a"></iframe>`:    {"https://www.youtube.com/embed/a"},
+		`<img src="a.png">`: nil,
+		`<script data-src="a.js">console.log('a');</script>`: nil,
+	}
+	for input, expected := range tests {
+		doc, err := html.Parse(strings.NewReader(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		var walk func(n *html.Node)
+		walk = func(n *html.Node) {
+			if n.Type == html.ElementNode {
+				for _, attr := range srcAttrs(n) {
+					got = append(got, attr.Val)
+				}
+			}
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				walk(c)
+			}
+		}
+		walk(doc)
+		if strings.Join(got, " ") != strings.Join(expected, " ") {
+			t.Errorf("%v: expected %q, got %q", input, expected, got)
+		}
+	}
+}
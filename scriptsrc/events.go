@@ -0,0 +1,110 @@
+package scriptsrc
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// EventHandlerAttributes are the standard event handler content attributes, such as onclick, whose
+// values are inline scripts.
+//
+// These are the attributes hashed by [ScriptSrc.AddFromHTML] when event handlers are included and
+// ScriptSrc.IsEventHandler is nil. Attributes can be appended to this to recognise non-standard
+// event handlers everywhere, or ScriptSrc.IsEventHandler set for more control.
+//
+// It's read once, the first time [IsEventHandlerAttribute] is called, so it must only be modified
+// before then, such as in an init function. Later modifications aren't supported, and are ignored.
+var EventHandlerAttributes = []string{
+	// Global event handlers.
+	"onabort", "onauxclick", "onbeforeinput", "onbeforematch", "onbeforetoggle", "onblur",
+	"oncancel", "oncanplay", "oncanplaythrough", "onchange", "onclick", "onclose", "oncommand",
+	"oncontextlost", "oncontextmenu", "oncontextrestored", "oncopy", "oncuechange", "oncut",
+	"ondblclick", "ondrag", "ondragend", "ondragenter", "ondragleave", "ondragover", "ondragstart",
+	"ondrop", "ondurationchange", "onemptied", "onended", "onerror", "onfocus", "onformdata",
+	"oninput", "oninvalid", "onkeydown", "onkeypress", "onkeyup", "onload", "onloadeddata",
+	"onloadedmetadata", "onloadstart", "onmousedown", "onmouseenter", "onmouseleave",
+	"onmousemove", "onmouseout", "onmouseover", "onmouseup", "onpaste", "onpause", "onplay",
+	"onplaying", "onprogress", "onratechange", "onreset", "onresize", "onscroll", "onscrollend",
+	"onsecuritypolicyviolation", "onseeked", "onseeking", "onselect", "onslotchange", "onstalled",
+	"onsubmit", "onsuspend", "ontimeupdate", "ontoggle", "onvolumechange", "onwaiting",
+	"onwebkitanimationend", "onwebkitanimationiteration", "onwebkitanimationstart",
+	"onwebkittransitionend", "onwheel",
+
+	// Window event handlers, allowed on body and frameset elements.
+	"onafterprint", "onbeforeprint", "onbeforeunload", "onhashchange", "onlanguagechange",
+	"onmessage", "onmessageerror", "onoffline", "ononline", "onpagehide", "onpagereveal",
+	"onpageshow", "onpageswap", "onpopstate", "onrejectionhandled", "onstorage",
+	"onunhandledrejection", "onunload",
+
+	// Pointer, touch, animation, transition, selection and fullscreen events.
+	"ongotpointercapture", "onlostpointercapture", "onpointercancel", "onpointerdown",
+	"onpointerenter", "onpointerleave", "onpointermove", "onpointerout", "onpointerover",
+	"onpointerrawupdate", "onpointerup", "ontouchcancel", "ontouchend", "ontouchmove",
+	"ontouchstart", "onanimationcancel", "onanimationend", "onanimationiteration",
+	"onanimationstart", "ontransitioncancel", "ontransitionend", "ontransitionrun",
+	"ontransitionstart", "onselectionchange", "onselectstart", "onfullscreenchange",
+	"onfullscreenerror",
+
+	// SVG event attributes.
+	"onbegin", "onend", "onrepeat", "onfocusin", "onfocusout",
+}
+
+// eventHandlerSet returns attributes as a set.
+func eventHandlerSet(attributes []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(attributes))
+	for _, attribute := range attributes {
+		set[attribute] = struct{}{}
+	}
+	return set
+}
+
+// eventHandlers is the set of EventHandlerAttributes, built the first time it's called.
+var eventHandlers = sync.OnceValue(func() map[string]struct{} {
+	return eventHandlerSet(EventHandlerAttributes)
+})
+
+// IsEventHandlerAttribute reports whether key is one of the [EventHandlerAttributes], ignoring case.
+func IsEventHandlerAttribute(key string) bool {
+	_, ok := eventHandlers()[strings.ToLower(key)]
+	return ok
+}
+
+// isEventHandler reports whether the attribute key should be treated as an event handler, using
+// scriptSrc.IsEventHandler if set.
+func (scriptSrc *ScriptSrc) isEventHandler(key string) bool {
+	if scriptSrc.IsEventHandler != nil {
+		return scriptSrc.IsEventHandler(key)
+	}
+	return IsEventHandlerAttribute(key)
+}
+
+// EventHandlersOnly returns a new ScriptSrc containing only the hashes of event handlers from
+// scriptSrc, along with their labels and contents, without any other sources.
+//
+// This isn't a working policy, but is useful for finding the event handlers that must be moved
+// into scripts to remove them from the policy, for example, using the labels to find which files
+// they're in.
+func (scriptSrc *ScriptSrc) EventHandlersOnly() *ScriptSrc {
+	handlers := &ScriptSrc{
+		DefaultHashAlgorithm: scriptSrc.DefaultHashAlgorithm,
+		HashEncoding:         scriptSrc.HashEncoding,
+		Hashes:               slices.Clone(scriptSrc.EventHandlerHashes),
+		EventHandlerHashes:   slices.Clone(scriptSrc.EventHandlerHashes),
+	}
+	for _, hash := range handlers.Hashes {
+		if label, ok := scriptSrc.Labels[hash]; ok {
+			if handlers.Labels == nil {
+				handlers.Labels = make(map[string]string)
+			}
+			handlers.Labels[hash] = label
+		}
+		if content, ok := scriptSrc.Contents[hash]; ok {
+			if handlers.Contents == nil {
+				handlers.Contents = make(map[string]string)
+			}
+			handlers.Contents[hash] = content
+		}
+	}
+	return handlers
+}
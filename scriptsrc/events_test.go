@@ -0,0 +1,86 @@
+package scriptsrc
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestIsEventHandler(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<button onclick="a" once="b" x-on="c"></button>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scriptSrc := ScriptSrc{DefaultHashAlgorithm: Sha256}
+	if err := scriptSrc.AddFromHTML(doc, true); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "'sha256-ypeBEsobvcr6wjGzmiPcTaeG7/gUfE5yuYB3ha/uSLs='"; scriptSrc.String() != expected {
+		t.Errorf("expected only onclick to be hashed (%v), got %v", expected, scriptSrc.String())
+	}
+
+	scriptSrc = ScriptSrc{
+		DefaultHashAlgorithm: Sha256,
+		IsEventHandler:       func(key string) bool { return key == "x-on" },
+	}
+	if err := scriptSrc.AddFromHTML(doc, true); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "'sha256-Ln0sA6lQeuJl7PW1NWiFpTOTogKdJBOUmXJloaJa78Y='"; scriptSrc.String() != expected {
+		t.Errorf("expected only x-on to be hashed (%v), got %v", expected, scriptSrc.String())
+	}
+}
+
+func TestIsEventHandlerAttribute(t *testing.T) {
+	matrix := map[string]bool{
+		"onclick":         true,
+		"onload":          true,
+		"onbeforeprint":   true,
+		"onpointerdown":   true,
+		"onClick":         true,
+		"ONLOAD":          true,
+		"ononline":        true,
+		"on":              false,
+		"once":            false,
+		"online":          false,
+		"onboarding":      false,
+		"data-onclick":    false,
+		"data-onboarding": false,
+		"click":           false,
+		"src":             false,
+	}
+	for key, expected := range matrix {
+		if got := IsEventHandlerAttribute(key); got != expected {
+			t.Errorf("IsEventHandlerAttribute(%q): expected %v, got %v", key, expected, got)
+		}
+	}
+
+	// The set is only built once, so the appended attribute can only be checked in a new set.
+	set := eventHandlerSet(append(slices.Clip(EventHandlerAttributes), "onboarding"))
+	if _, ok := set["onboarding"]; !ok {
+		t.Errorf("expected an appended attribute to be an event handler")
+	}
+}
+
+func TestEventHandlersOnly(t *testing.T) {
+	scriptSrc := New(WithKeepContents(true))
+	err := scriptSrc.AddFromHTMLString(`<script src="/app.js"></script><script>init()</script><button onclick="go()">Go</button>`, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handlers := scriptSrc.EventHandlersOnly()
+	expected := ScriptSrc{}
+	expected.AddInline("go()")
+	if handlers.String() != expected.String() {
+		t.Errorf("expected %v, got %v", &expected, handlers)
+	}
+	if contents := handlers.InlineContents(); len(contents) != 1 || contents[0] != "go()" {
+		t.Errorf("expected the content of the event handler, got %q", contents)
+	}
+	if len(scriptSrc.Hashes) != 2 {
+		t.Errorf("expected scriptSrc to be unchanged, got %v", scriptSrc)
+	}
+}
@@ -0,0 +1,40 @@
+package scriptsrc_test
+
+import (
+	"fmt"
+
+	"github.com/JOT85/script-src-generator/scriptsrc"
+)
+
+func ExampleScriptSrc_AllowsInline() {
+	scriptSrc, err := scriptsrc.ScriptSrcFromHTMLString(`<script>console.log("Hello");</script>`, false)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(scriptSrc.AllowsInline(`console.log("Hello");`))
+	fmt.Println(scriptSrc.AllowsInline(`console.log("Goodbye");`))
+	// Output:
+	// true
+	// false
+}
+
+func ExampleScriptSrc_AllowsHost() {
+	scriptSrc, err := scriptsrc.ScriptSrcFromHTMLString(
+		`<script src="/js/app.js"></script><script src="https://cdn.example.com/lib.js"></script>`,
+		false,
+	)
+	if err != nil {
+		panic(err)
+	}
+	for _, src := range []string{
+		"/js/other.js",
+		"https://cdn.example.com/other.js",
+		"https://evil.example.com/lib.js",
+	} {
+		fmt.Println(src, scriptSrc.AllowsHost(src))
+	}
+	// Output:
+	// /js/other.js true
+	// https://cdn.example.com/other.js true
+	// https://evil.example.com/lib.js false
+}
@@ -0,0 +1,76 @@
+package scriptsrc
+
+import (
+	"fmt"
+	"os"
+)
+
+// ExternalScripts specifies how external scripts (script tags with a src attribute) are allowed.
+type ExternalScripts uint8
+
+const (
+	// ExternalHosts allows external scripts by adding 'self' or their host. This is the default.
+	ExternalHosts ExternalScripts = 0
+
+	// ExternalHashes allows external scripts by adding the hash of their content, instead of their
+	// host. ScriptSrc.ExternalScriptContent must be set.
+	ExternalHashes ExternalScripts = 1
+
+	// ExternalHostsAndHashes adds both the host and the hash of external scripts.
+	ExternalHostsAndHashes ExternalScripts = 2
+)
+
+// ExternalScriptContentFunc returns the content of the external script with the given src
+// attribute, as it appears in the HTML.
+type ExternalScriptContentFunc func(src string) (string, error)
+
+// addExternal adds the sources allowing the external script src, according to
+// scriptSrc.ExternalScripts.
+//
+// src is checked as scriptSrc.addFoundSrc does in every mode, so insecure and invalid srcs are
+// never hashed: insecure srcs are errors if ErrorOnInsecure is set, and otherwise, like other
+// invalid srcs, are ignored with a warning. Errors getting the content of the script are returned,
+// along with errors for srcs that require a host if NoHosts is set and the host is added.
+func (scriptSrc *ScriptSrc) addExternal(src string) error {
+	host, ok, err := scriptSrc.foundHostSource("script", src)
+	if !ok {
+		return err
+	}
+	if scriptSrc.ExternalScripts != ExternalHashes {
+		err := scriptSrc.addHostSource(host, rawSrc(src))
+		if err != nil {
+			return err
+		}
+	}
+	if scriptSrc.ExternalScripts == ExternalHosts {
+		return nil
+	}
+	if scriptSrc.ExternalScriptContent == nil {
+		return fmt.Errorf("ExternalScriptContent must be set to hash external script: %v", src)
+	}
+	content, err := scriptSrc.ExternalScriptContent(src)
+	if err != nil {
+		return fmt.Errorf("failed to get content of external script %v: %w", src, err)
+	}
+	scriptSrc.AddInline(content)
+	return nil
+}
+
+// ExternalScriptContentFromFiles returns an ExternalScriptContentFunc that reads the content of
+// external scripts from local files.
+//
+// files maps the src attribute of each script, exactly as it appears in the HTML, to the path of
+// the file containing its content.
+func ExternalScriptContentFromFiles(files map[string]string) ExternalScriptContentFunc {
+	return func(src string) (string, error) {
+		path, ok := files[src]
+		if !ok {
+			return "", fmt.Errorf("no file mapped for script src")
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return string(content), nil
+	}
+}
@@ -0,0 +1,164 @@
+package scriptsrc
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestExternalScripts(t *testing.T) {
+	const page = `<script src="https://cdn.example.com/external.js"></script>`
+	const hash = "'sha512-5zvqfxZcoHE5KMnx82Ak1BojUw68qW3tFc7ZrQqf4HBIWAEngEjRHZT2YQvxBHrwYIx37iRlY8sxp0tJ0MoRqA=='"
+	files := ExternalScriptContentFromFiles(map[string]string{
+		"https://cdn.example.com/external.js": "./tests/external/external.js",
+	})
+	tests := []struct {
+		mode     ExternalScripts
+		expected string
+	}{
+		{ExternalHosts, "https://cdn.example.com"},
+		{ExternalHashes, hash},
+		{ExternalHostsAndHashes, hash + " https://cdn.example.com"},
+	}
+	for _, test := range tests {
+		doc, err := html.Parse(strings.NewReader(page))
+		if err != nil {
+			t.Fatal(err)
+		}
+		scriptSrc := ScriptSrc{ExternalScripts: test.mode, ExternalScriptContent: files}
+		err = scriptSrc.AddFromHTML(doc, true)
+		if err != nil {
+			t.Error(err)
+		} else if got := scriptSrc.String(); got != test.expected {
+			t.Errorf("mismatched script-src for mode %v: expected %v, got %v", test.mode, test.expected, got)
+		}
+	}
+
+	doc, err := html.Parse(strings.NewReader(`<script src="https://cdn.example.com/unmapped.js"></script>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scriptSrc := ScriptSrc{ExternalScripts: ExternalHashes, ExternalScriptContent: files}
+	if err := scriptSrc.AddFromHTML(doc, true); err == nil {
+		t.Errorf("expected an error for an unmapped script")
+	}
+}
+
+func TestErrorOnInsecure(t *testing.T) {
+	const page = `<script src="http://cdn.example.com/insecure.js"></script>`
+	for _, errorOnInsecure := range []bool{false, true} {
+		doc, err := html.Parse(strings.NewReader(page))
+		if err != nil {
+			t.Fatal(err)
+		}
+		scriptSrc := New(WithErrorOnInsecure(errorOnInsecure))
+		err = scriptSrc.AddFromHTML(doc, true)
+		if errorOnInsecure {
+			if !errors.Is(err, ErrInsecure) {
+				t.Errorf("expected an insecure error, got %v", err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Error(err)
+		} else if len(scriptSrc.Hosts) != 0 || len(scriptSrc.Warnings) != 1 {
+			t.Errorf("expected the insecure src to be ignored with a warning, got hosts %v and warnings %v", scriptSrc.Hosts, scriptSrc.Warnings)
+		}
+		if !slices.Equal(scriptSrc.InsecureHosts, []string{"http://cdn.example.com"}) {
+			t.Errorf("expected the insecure host to be recorded, got %v", scriptSrc.InsecureHosts)
+		}
+	}
+
+	// Links that fetch insecure scripts are always errors.
+	var scriptSrc ScriptSrc
+	err := scriptSrc.AddFromHTMLString(`<link rel="modulepreload" href="http://cdn.example.com/insecure.js">`, false)
+	if !errors.Is(err, ErrInsecure) {
+		t.Errorf("expected an insecure error for a link, got %v", err)
+	}
+}
+
+func TestExternalHashesInsecure(t *testing.T) {
+	const page = `<script src="http://cdn.example.com/insecure.js"></script>`
+	for _, errorOnInsecure := range []bool{false, true} {
+		fetched := false
+		scriptSrc := ScriptSrc{
+			ExternalScripts: ExternalHashes,
+			ExternalScriptContent: func(src string) (string, error) {
+				fetched = true
+				return "insecure", nil
+			},
+			ErrorOnInsecure: errorOnInsecure,
+		}
+		err := scriptSrc.AddFromHTMLString(page, false)
+		if fetched {
+			t.Errorf("expected the insecure script not to be fetched, with ErrorOnInsecure %v", errorOnInsecure)
+		}
+		if errorOnInsecure {
+			if !errors.Is(err, ErrInsecure) {
+				t.Errorf("expected an insecure error, got %v", err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Error(err)
+		} else if len(scriptSrc.Hashes) != 0 || len(scriptSrc.Warnings) != 1 {
+			t.Errorf("expected the insecure src to be ignored with a warning, got hashes %v and warnings %v", scriptSrc.Hashes, scriptSrc.Warnings)
+		}
+		if !slices.Equal(scriptSrc.InsecureHosts, []string{"http://cdn.example.com"}) {
+			t.Errorf("expected the insecure host to be recorded, got %v", scriptSrc.InsecureHosts)
+		}
+	}
+}
+
+func TestIntegrityHashes(t *testing.T) {
+	scriptSrc, err := PreviewFromHTMLFile("./tests/integrity.html", true, WithIntegrityHashes(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scriptSrc.Hashes) != 0 {
+		t.Errorf("expected no integrity hashes without 'strict-dynamic', got %v", scriptSrc.Hashes)
+	}
+
+	scriptSrc, err = PreviewFromHTMLFile("./tests/integrity.html", true, WithIntegrityHashes(true), WithStrictDynamic(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"sha384-oqVuAfXRKap7fdgcCY5uykM6+R9GqQ8K/uxy9rx7HNQlGYl1kPzQho1wx4JwY8wC",
+		"sha256-47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=",
+		"sha512-z4PhNX7vuL3xVChQ1m2AB9Yg5AULVxXcg/SpIdNs6c5H0NE8XYXysP+DGNKHfuwvY7kxvUdBeoGlODJ6+SfaPg==",
+	}
+	if !slices.Equal(scriptSrc.Hashes, expected) {
+		t.Errorf("expected hashes %v, got %v", expected, scriptSrc.Hashes)
+	}
+	if len(scriptSrc.Warnings) != 1 {
+		t.Errorf("expected a warning for the invalid integrity hash, got %q", scriptSrc.Warnings)
+	}
+}
+
+func TestCrossOriginWarning(t *testing.T) {
+	const integrity = `integrity="sha384-oqVuAfXRKap7fdgcCY5uykM6+R9GqQ8K/uxy9rx7HNQlGYl1kPzQho1wx4JwY8wC"`
+	tests := []struct {
+		page string
+		warn bool
+	}{
+		{`<script src="https://cdn.example.com/lib.js" ` + integrity + `></script>`, true},
+		{`<script src="//cdn.example.com/lib.js" ` + integrity + `></script>`, true},
+		{`<script src="https://cdn.example.com/lib.js" crossorigin ` + integrity + `></script>`, false},
+		{`<script type="module" src="https://cdn.example.com/lib.js" ` + integrity + `></script>`, false},
+		{`<script src="/js/lib.js" ` + integrity + `></script>`, false},
+		{`<script src="https://cdn.example.com/lib.js"></script>`, false},
+	}
+	for _, test := range tests {
+		var scriptSrc ScriptSrc
+		if err := scriptSrc.AddFromHTMLString(test.page, false); err != nil {
+			t.Fatal(err)
+		}
+		if warned := len(scriptSrc.Warnings) == 1; warned != test.warn {
+			t.Errorf("expected a warning %v for %v, got %q", test.warn, test.page, scriptSrc.Warnings)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package scriptsrc
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// OutputFormat specifies how [ScriptSrc.Render] formats a script-src.
+type OutputFormat uint8
+
+const (
+	// OutputPlain is the value of the script-src directive, exactly as returned by [ScriptSrc.String].
+	OutputPlain OutputFormat = iota
+
+	// OutputNginx is an nginx add_header directive setting the Content-Security-Policy header.
+	//
+	// Any Files, and then any labels, are emitted as # comments above the directive.
+	OutputNginx
+
+	// OutputJSON is a JSON object, containing the script-src directive value under "script-src",
+	// along with each of the sources, and any labels and files.
+	OutputJSON
+)
+
+// outputFormatNames are the names of each OutputFormat, as used by ParseOutputFormat.
+var outputFormatNames = [...]string{
+	OutputPlain: "plain",
+	OutputNginx: "nginx",
+	OutputJSON:  "json",
+}
+
+// String returns the name of the format, such as "nginx".
+func (format OutputFormat) String() string {
+	if int(format) < len(outputFormatNames) {
+		return outputFormatNames[format]
+	}
+	return fmt.Sprintf("OutputFormat(%d)", format)
+}
+
+// ParseOutputFormat returns the OutputFormat with the given name, one of "plain", "nginx" or "json".
+func ParseOutputFormat(name string) (OutputFormat, error) {
+	for format, formatName := range outputFormatNames {
+		if formatName == name {
+			return OutputFormat(format), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown output format: %v", name)
+}
+
+// commentPrefixes are the line comment prefixes of each OutputFormat that supports comments.
+var commentPrefixes = [...]string{
+	OutputNginx: "# ",
+}
+
+// writeComments writes comments, in a format using prefix for line comments, listing the Files,
+// and then the label of each hash, if there are any.
+func (scriptSrc *ScriptSrc) writeComments(b *strings.Builder, prefix string) {
+	comment := func(format string, args ...any) {
+		b.WriteString(prefix)
+		b.WriteString(strings.ReplaceAll(fmt.Sprintf(format, args...), "\n", " "))
+		b.WriteByte('\n')
+	}
+	if len(scriptSrc.Files) > 0 {
+		comment("script-src generated from:")
+		for _, file := range scriptSrc.Files {
+			comment("  %v", file)
+		}
+	}
+	for _, hash := range scriptSrc.Hashes {
+		if label, ok := scriptSrc.Labels[hash]; ok {
+			comment("'%v': %v", hash, label)
+		}
+	}
+}
+
+// checkNginxValue returns an error if value, the script-src directive value, contains a source that
+// can't be put inside the double quoted value of an nginx add_header directive, since it contains a
+// quote, a backslash, or a control character, such as a newline, which would break or inject into
+// the config.
+func checkNginxValue(value string) error {
+	for _, src := range strings.Split(value, " ") {
+		for _, r := range src {
+			if r == '"' || r == '\\' || unicode.IsControl(r) {
+				return fmt.Errorf("source %q contains %q, which can't be included in an nginx add_header directive", src, r)
+			}
+		}
+	}
+	return nil
+}
+
+// jsonHash is a hash source, and its optional label, as formatted by OutputJSON.
+type jsonHash struct {
+	Hash  string `json:"hash"`
+	Label string `json:"label,omitempty"`
+}
+
+// jsonOutput is the structure formatted by OutputJSON.
+type jsonOutput struct {
+	ScriptSrc     string     `json:"script-src"`
+	Self          bool       `json:"self"`
+	StrictDynamic bool       `json:"strict-dynamic"`
+	ReportSample  bool       `json:"report-sample"`
+	Hashes        []jsonHash `json:"hashes"`
+	Hosts         []string   `json:"hosts"`
+	Others        []string   `json:"others"`
+	Files         []string   `json:"files,omitempty"`
+}
+
+// Render formats scriptSrc in the requested format.
+//
+// Unlike String, formats that support comments or extra fields include the Files and the Labels of
+// hashes. OutputPlain never includes comments.
+//
+// OutputNginx returns an error if any source contains a double quote, a backslash or a control
+// character, such as a newline, since it couldn't be safely quoted in the directive.
+func (scriptSrc *ScriptSrc) Render(format OutputFormat) (string, error) {
+	switch format {
+	case OutputPlain:
+		return scriptSrc.String(), nil
+
+	case OutputNginx:
+		value := scriptSrc.String()
+		if err := checkNginxValue(value); err != nil {
+			return "", err
+		}
+		var b strings.Builder
+		scriptSrc.writeComments(&b, commentPrefixes[format])
+		fmt.Fprintf(&b, "add_header Content-Security-Policy \"script-src %v\" always;", value)
+		return b.String(), nil
+
+	case OutputJSON:
+		output := jsonOutput{
+			ScriptSrc:     scriptSrc.String(),
+			Self:          scriptSrc.includesSelf(),
+			StrictDynamic: scriptSrc.StrictDynamic,
+			ReportSample:  scriptSrc.ReportSample,
+			Hashes:        make([]jsonHash, 0, len(scriptSrc.Hashes)),
+			Hosts:         append([]string{}, scriptSrc.Hosts...),
+			Others:        append([]string{}, scriptSrc.Others...),
+			Files:         scriptSrc.Files,
+		}
+		for _, hash := range scriptSrc.Hashes {
+			output.Hashes = append(output.Hashes, jsonHash{hash, scriptSrc.Labels[hash]})
+		}
+		b, err := json.MarshalIndent(output, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+
+	default:
+		return "", fmt.Errorf("invalid OutputFormat: %v", format)
+	}
+}
+
+// FormatOptions configures [ScriptSrc.Format].
+type FormatOptions struct {
+	// Separator is written between each source. If empty, a single space is used, as String does.
+	Separator string
+
+	// Indent is written before each source.
+	Indent string
+}
+
+// Format formats the sources of scriptSrc, like String, but with the separator and indentation in
+// opts, for example, one source per line for readability:
+//
+//	scriptSrc.Format(FormatOptions{Separator: "\n", Indent: "    "})
+//
+// The result is only valid in a header value if the separator and indentation are only spaces and
+// tabs, such as the default. Newlines are never valid in a header value, so other separators are
+// only for human-readable output, such as comments or config files that join the lines.
+func (scriptSrc *ScriptSrc) Format(opts FormatOptions) string {
+	separator := opts.Separator
+	if separator == "" {
+		separator = " "
+	}
+	var b strings.Builder
+	for i, src := range scriptSrc.directiveSources() {
+		if i > 0 {
+			b.WriteString(separator)
+		}
+		b.WriteString(opts.Indent)
+		b.WriteString(src)
+	}
+	return b.String()
+}
+
+// Report formats scriptSrc as a human-readable report, with the sources grouped by type, for
+// reviewing large policies. For example:
+//
+//	Self: yes
+//	Strict dynamic: no
+//	Report sample: no
+//	Hashes (1):
+//	  'sha512-...' (index.html)
+//	Hosts (1):
+//	  https://challenges.cloudflare.com
+//	Others (0)
+//
+// The label of each hash, if any, is shown after it. Use String for the header value.
+func (scriptSrc *ScriptSrc) Report() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Self: %v\n", yesNo(scriptSrc.includesSelf()))
+	fmt.Fprintf(&b, "Strict dynamic: %v\n", yesNo(scriptSrc.StrictDynamic))
+	fmt.Fprintf(&b, "Report sample: %v\n", yesNo(scriptSrc.ReportSample))
+	hashes := make([]string, len(scriptSrc.Hashes))
+	for i, hash := range scriptSrc.Hashes {
+		hashes[i] = "'" + hash + "'"
+		if label := scriptSrc.Labels[hash]; label != "" {
+			hashes[i] += " (" + label + ")"
+		}
+	}
+	writeReportSection(&b, "Hashes", hashes)
+	writeReportSection(&b, "Hosts", scriptSrc.Hosts)
+	writeReportSection(&b, "Others", scriptSrc.Others)
+	return b.String()
+}
+
+// writeReportSection writes a section of [ScriptSrc.Report], with the number of entries in the
+// heading, and each entry on an indented line.
+func writeReportSection(b *strings.Builder, heading string, entries []string) {
+	if len(entries) == 0 {
+		fmt.Fprintf(b, "%v (0)\n", heading)
+		return
+	}
+	fmt.Fprintf(b, "%v (%v):\n", heading, len(entries))
+	for _, entry := range entries {
+		b.WriteString("  ")
+		b.WriteString(entry)
+		b.WriteByte('\n')
+	}
+}
+
+// yesNo formats b as "yes" or "no".
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
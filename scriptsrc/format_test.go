@@ -0,0 +1,122 @@
+package scriptsrc
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	scriptSrc := ScriptSrc{DefaultHashAlgorithm: Sha256, Hosts: []string{"https://example.com"}}
+	scriptSrc.AddInlineWithLabel("a", "index.html")
+	scriptSrc.AddInline("b")
+
+	expected := map[OutputFormat]string{
+		OutputPlain: "'sha256-ypeBEsobvcr6wjGzmiPcTaeG7/gUfE5yuYB3ha/uSLs=' 'sha256-PiPoFgA5WUoziU9lZOGxNIu9egCI1CxKy3PurtWcAJ0=' https://example.com",
+		OutputNginx: `# 'sha256-ypeBEsobvcr6wjGzmiPcTaeG7/gUfE5yuYB3ha/uSLs=': index.html
+add_header Content-Security-Policy "script-src 'sha256-ypeBEsobvcr6wjGzmiPcTaeG7/gUfE5yuYB3ha/uSLs=' 'sha256-PiPoFgA5WUoziU9lZOGxNIu9egCI1CxKy3PurtWcAJ0=' https://example.com" always;`,
+		OutputJSON: `{
+  "script-src": "'sha256-ypeBEsobvcr6wjGzmiPcTaeG7/gUfE5yuYB3ha/uSLs=' 'sha256-PiPoFgA5WUoziU9lZOGxNIu9egCI1CxKy3PurtWcAJ0=' https://example.com",
+  "self": false,
+  "strict-dynamic": false,
+  "report-sample": false,
+  "hashes": [
+    {
+      "hash": "sha256-ypeBEsobvcr6wjGzmiPcTaeG7/gUfE5yuYB3ha/uSLs=",
+      "label": "index.html"
+    },
+    {
+      "hash": "sha256-PiPoFgA5WUoziU9lZOGxNIu9egCI1CxKy3PurtWcAJ0="
+    }
+  ],
+  "hosts": [
+    "https://example.com"
+  ],
+  "others": []
+}`,
+	}
+	for format, expected := range expected {
+		got, err := scriptSrc.Render(format)
+		if err != nil {
+			t.Errorf("failed to render %v: %v", format, err)
+		} else if got != expected {
+			t.Errorf("mismatched %v output: expected %v, got %v", format, expected, got)
+		}
+	}
+}
+
+func TestRenderNginxInvalid(t *testing.T) {
+	for _, other := range []string{`'a" always; add_header X "b'`, `'a\'`, "'a\nb'"} {
+		scriptSrc := ScriptSrc{Self: true, Others: []string{other}}
+		if got, err := scriptSrc.Render(OutputNginx); err == nil {
+			t.Errorf("expected an error for %q, got %v", other, got)
+		}
+	}
+	scriptSrc := ScriptSrc{Self: true, Others: []string{" 'unsafe-eval' "}}
+	if _, err := scriptSrc.Render(OutputNginx); err != nil {
+		t.Errorf("unexpected error with surrounding whitespace: %v", err)
+	}
+}
+
+func TestRenderFiles(t *testing.T) {
+	scriptSrc := ScriptSrc{DefaultHashAlgorithm: Sha256, Files: []string{"index.html", "about\n.html"}}
+	scriptSrc.AddInlineWithLabel("a", "index.html")
+
+	expected := `# script-src generated from:
+#   index.html
+#   about .html
+# 'sha256-ypeBEsobvcr6wjGzmiPcTaeG7/gUfE5yuYB3ha/uSLs=': index.html
+add_header Content-Security-Policy "script-src 'sha256-ypeBEsobvcr6wjGzmiPcTaeG7/gUfE5yuYB3ha/uSLs='" always;`
+	if got, _ := scriptSrc.Render(OutputNginx); got != expected {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	if got, _ := scriptSrc.Render(OutputPlain); got != scriptSrc.String() {
+		t.Errorf("expected plain output without comments, got %v", got)
+	}
+
+	var merged ScriptSrc
+	merged.Merge(&scriptSrc)
+	merged.Merge(&ScriptSrc{Files: []string{"index.html", "contact.html"}})
+	if expected := []string{"index.html", "about\n.html", "contact.html"}; !slices.Equal(merged.Files, expected) {
+		t.Errorf("expected merged files %q, got %q", expected, merged.Files)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	scriptSrc := ScriptSrc{Self: true, Hosts: []string{"https://example.com"}, Others: []string{"'unsafe-eval'"}}
+	tests := []struct {
+		opts     FormatOptions
+		expected string
+	}{
+		{FormatOptions{}, scriptSrc.String()},
+		{FormatOptions{Separator: "\n", Indent: "  "}, "  'self'\n  https://example.com\n  'unsafe-eval'"},
+		{FormatOptions{Separator: ", "}, "'self', https://example.com, 'unsafe-eval'"},
+	}
+	for _, test := range tests {
+		if got := scriptSrc.Format(test.opts); got != test.expected {
+			t.Errorf("expected %q for %+v, got %q", test.expected, test.opts, got)
+		}
+	}
+}
+
+func TestReport(t *testing.T) {
+	scriptSrc := ScriptSrc{
+		Self:         true,
+		ReportSample: true,
+		Hashes:       []string{"sha256-a=", "sha256-b="},
+		Labels:       map[string]string{"sha256-a=": "index.html"},
+		Hosts:        []string{"https://challenges.cloudflare.com"},
+	}
+	expected := `Self: yes
+Strict dynamic: no
+Report sample: yes
+Hashes (2):
+  'sha256-a=' (index.html)
+  'sha256-b='
+Hosts (1):
+  https://challenges.cloudflare.com
+Others (0)
+`
+	if got := scriptSrc.Report(); got != expected {
+		t.Errorf("expected:\n%v\ngot:\n%v", expected, got)
+	}
+}
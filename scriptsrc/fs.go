@@ -0,0 +1,78 @@
+package scriptsrc
+
+import "io/fs"
+
+// readHTMLFS reads the file from path in fsys, transparently decompressing it if it's gzip
+// compressed.
+func readHTMLFS(fsys fs.FS, path string) ([]byte, error) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readHTML(f, path)
+}
+
+// AddFromFS is like scriptSrc.AddFromHTMLFile, but reads the file from path in fsys, such as an
+// [embed.FS], instead of the OS filesystem.
+//
+// The path must be valid for fsys, see [fs.ValidPath].
+func (scriptSrc *ScriptSrc) AddFromFS(fsys fs.FS, path string, includeEventHandlers bool) error {
+	if err := scriptSrc.checkSealed("AddFromFS"); err != nil {
+		return err
+	}
+	data, err := readHTMLFS(fsys, path)
+	if err != nil {
+		return err
+	}
+	return scriptSrc.addFromHTMLData(path, data, includeEventHandlers)
+}
+
+// AddFromFSGlob calls scriptSrc.AddFromFS for every file in fsys matching the glob pattern, see
+// [fs.Glob].
+func (scriptSrc *ScriptSrc) AddFromFSGlob(fsys fs.FS, pattern string, includeEventHandlers bool) error {
+	if err := scriptSrc.checkSealed("AddFromFSGlob"); err != nil {
+		return err
+	}
+	paths, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		err := scriptSrc.AddFromFS(fsys, path, includeEventHandlers)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddFromFSDir recursively walks the directory root in fsys, calling scriptSrc.AddFromFS for every
+// file with a .html, .htm, .html.gz or .htm.gz extension. Use "." to walk all of fsys.
+func (scriptSrc *ScriptSrc) AddFromFSDir(fsys fs.FS, root string, includeEventHandlers bool) error {
+	if err := scriptSrc.checkSealed("AddFromFSDir"); err != nil {
+		return err
+	}
+	return fs.WalkDir(fsys, root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !isHTMLPath(path) {
+			return nil
+		}
+		return scriptSrc.AddFromFS(fsys, path, includeEventHandlers)
+	})
+}
+
+// ScriptSrcFromFS generates the script-src required to load any of the HTML files within the
+// directory root in fsys, recursively.
+//
+// The input files must be truested HTML files! See the package documentation if you're unsure.
+func ScriptSrcFromFS(fsys fs.FS, root string, includeEventHandlers bool) (*ScriptSrc, error) {
+	scriptSrc := &ScriptSrc{}
+	err := scriptSrc.AddFromFSDir(fsys, root, includeEventHandlers)
+	if err != nil {
+		return nil, err
+	}
+	return scriptSrc, nil
+}
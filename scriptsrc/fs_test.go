@@ -0,0 +1,55 @@
+package scriptsrc
+
+import (
+	"os"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+func TestAddFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":   {Data: []byte(`<script src="https://example.com/a.js"></script><script>console.log("a");</script>`)},
+		"sub/page.htm": {Data: []byte(`<button onclick="go()">Go</button>`)},
+		"ignored.txt":  {Data: []byte(`<script src="https://ignored.example.com/a.js"></script>`)},
+	}
+
+	scriptSrc, err := ScriptSrcFromFS(fsys, ".", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := ScriptSrc{Hosts: []string{"https://example.com"}}
+	expected.AddInline(`console.log("a");`)
+	expected.AddInline("go()")
+	if scriptSrc.String() != expected.String() {
+		t.Errorf("expected %v, got %v", &expected, scriptSrc)
+	}
+
+	globbed := ScriptSrc{}
+	err = globbed.AddFromFSGlob(fsys, "*.html", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(globbed.Hosts, expected.Hosts) || len(globbed.Hashes) != 1 {
+		t.Errorf("expected only index.html to be added, got %v", &globbed)
+	}
+
+	err = globbed.AddFromFS(fsys, "missing.html", false)
+	if err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+}
+
+func TestAddFromFSMatchesDir(t *testing.T) {
+	expected, err := ScriptSrcFromHTMLDir("./tests", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scriptSrc, err := ScriptSrcFromFS(os.DirFS("./tests"), ".", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scriptSrc.String() != expected.String() {
+		t.Errorf("expected %v, got %v", expected, scriptSrc)
+	}
+}
@@ -0,0 +1,77 @@
+package scriptsrc
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func FuzzAddFromHTML(f *testing.F) {
+	for _, path := range htmlTestFiles() {
+		if strings.HasSuffix(path, ".gz") {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data, true, uint8(Sha512), uint8(StdBase64))
+	}
+	f.Add([]byte(`<script>a</script><script src="//[::1]/x.js"></script><b onclick="b">`), true, uint8(Sha256), uint8(URLBase64))
+	f.Add([]byte(`<svg><script>c<!--<script>`), false, uint8(255), uint8(StdBase64))
+	f.Fuzz(func(t *testing.T, data []byte, includeEventHandlers bool, alg, encoding uint8) {
+		// Invalid algorithms and encodings must be errors, not panics.
+		scriptSrc := ScriptSrc{DefaultHashAlgorithm: HashAlgorithm(alg), HashEncoding: HashEncoding(encoding)}
+		err := scriptSrc.AddFromHTMLReader(bytes.NewReader(data), includeEventHandlers)
+		if err != nil {
+			return
+		}
+		// Any sources found must form a valid policy.
+		if err := scriptSrc.Validate(); err != nil {
+			t.Errorf("invalid script-src %v: %v", &scriptSrc, err)
+		}
+	})
+}
+
+func FuzzPolicyAddFromHTML(f *testing.F) {
+	f.Add([]byte(`<script>a</script><iframe src="https://www.youtube.com/embed/a"></iframe>`), true, uint8(Sha512), uint8(StdBase64))
+	f.Add([]byte(`<frame src="//[::1]/"><b onclick="b">`), true, uint8(255), uint8(StdBase64))
+	f.Add([]byte(`<script>a</script>`), false, uint8(Sha256), uint8(255))
+	f.Fuzz(func(t *testing.T, data []byte, includeEventHandlers bool, alg, encoding uint8) {
+		// As for ScriptSrc, invalid algorithms and encodings must be errors, not panics.
+		policy := Policy{ScriptSrc: ScriptSrc{DefaultHashAlgorithm: HashAlgorithm(alg), HashEncoding: HashEncoding(encoding)}}
+		err := policy.AddFromHTMLReader(bytes.NewReader(data), includeEventHandlers)
+		if err != nil {
+			return
+		}
+		if err := policy.ScriptSrc.Validate(); err != nil {
+			t.Errorf("invalid script-src %v: %v", &policy.ScriptSrc, err)
+		}
+	})
+}
+
+func FuzzAddFromHTMLFile(f *testing.F) {
+	// Errors from files are annotated with the position of the script, which reparses the data.
+	f.Add([]byte(`<script src="http://insecure.example.com/a.js"></script>`))
+	f.Add([]byte("<p>\n<script src=\"https://[::1]/x.js\">\n</script>"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		scriptSrc := ScriptSrc{ErrorOnInsecure: true}
+		scriptSrc.addFromHTMLData("fuzz.html", data, true)
+	})
+}
+
+func FuzzAddSrc(f *testing.F) {
+	for _, src := range []string{"/a.js", "https://cdn.example.com:8443/a.js", "//[::1]/a.js", "http://a", "https://a b/"} {
+		f.Add(src)
+	}
+	f.Fuzz(func(t *testing.T, src string) {
+		var scriptSrc ScriptSrc
+		if err := scriptSrc.AddSrc(src); err != nil {
+			return
+		}
+		if err := scriptSrc.Validate(); err != nil {
+			t.Errorf("AddSrc(%q) added an invalid source %v: %v", src, &scriptSrc, err)
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package scriptsrc
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// CSPLevel is a version of the Content Security Policy specification that browsers support, used
+// by ScriptSrc.TargetLevel.
+type CSPLevel uint8
+
+const (
+	// CSPLevelAny targets every current browser, without assuming support for any level beyond
+	// CSP Level 2, but without warning about CSP Level 3 features. This is the default.
+	CSPLevelAny CSPLevel = iota
+
+	// CSPLevel2 targets browsers only supporting CSP Level 2, so warns about any CSP Level 3
+	// features used, which those browsers ignore.
+	CSPLevel2
+
+	// CSPLevel3 targets browsers supporting CSP Level 3, so [ScriptSrc.Optimize] removes sources
+	// that are only needed by older browsers.
+	CSPLevel3
+)
+
+// cspLevelNames are the names of each CSPLevel, as used by ParseCSPLevel.
+var cspLevelNames = [...]string{
+	CSPLevelAny: "any",
+	CSPLevel2:   "2",
+	CSPLevel3:   "3",
+}
+
+// String returns the name of the level, such as "2".
+func (level CSPLevel) String() string {
+	if int(level) < len(cspLevelNames) {
+		return cspLevelNames[level]
+	}
+	return fmt.Sprintf("CSPLevel(%d)", level)
+}
+
+// ParseCSPLevel returns the CSPLevel with the given name, one of "any", "2" or "3".
+func ParseCSPLevel(name string) (CSPLevel, error) {
+	for level, levelName := range cspLevelNames {
+		if levelName == name {
+			return CSPLevel(level), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown CSP level: %v", name)
+}
+
+// level3Feature is a CSP Level 3 feature that scriptSrc may use, reported by LevelWarnings.
+type level3Feature struct {
+	name string
+	uses func(scriptSrc *ScriptSrc) bool
+}
+
+// hasOther returns a function reporting whether keyword is in the Others of a ScriptSrc.
+func hasOther(keyword string) func(scriptSrc *ScriptSrc) bool {
+	return func(scriptSrc *ScriptSrc) bool {
+		return slices.ContainsFunc(scriptSrc.Others, func(src string) bool {
+			return strings.EqualFold(strings.TrimSpace(src), keyword)
+		})
+	}
+}
+
+// level3Features are the CSP Level 3 features reported by LevelWarnings, in the order they are
+// reported.
+var level3Features = []level3Feature{
+	{"'strict-dynamic'", func(scriptSrc *ScriptSrc) bool { return scriptSrc.StrictDynamic }},
+	{"'unsafe-hashes'", hasOther("'unsafe-hashes'")},
+	{"hashes of event handlers", func(scriptSrc *ScriptSrc) bool { return len(scriptSrc.EventHandlerHashes) > 0 }},
+	{"hashes of external scripts", func(scriptSrc *ScriptSrc) bool {
+		return scriptSrc.ExternalScripts != ExternalHosts || (scriptSrc.IntegrityHashes && scriptSrc.StrictDynamic)
+	}},
+	{"'report-sample'", func(scriptSrc *ScriptSrc) bool {
+		return scriptSrc.ReportSample || hasOther("'report-sample'")(scriptSrc)
+	}},
+	{"'wasm-unsafe-eval'", hasOther("'wasm-unsafe-eval'")},
+}
+
+// LevelWarnings returns a warning for each feature used by scriptSrc that requires a higher CSP
+// level than TargetLevel, and so won't work in the targeted browsers.
+//
+// Currently, only CSPLevel2 has any warnings, for CSP Level 3 features such as 'strict-dynamic'.
+// They're also included in [ScriptSrc.Recommendations].
+func (scriptSrc *ScriptSrc) LevelWarnings() []string {
+	if scriptSrc.TargetLevel != CSPLevel2 {
+		return nil
+	}
+	var warnings []string
+	for _, feature := range level3Features {
+		if feature.uses(scriptSrc) {
+			warnings = append(warnings, "CSP Level 3 is required for "+feature.name+", which browsers only supporting CSP Level 2 ignore")
+		}
+	}
+	return warnings
+}
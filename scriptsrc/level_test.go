@@ -0,0 +1,57 @@
+package scriptsrc
+
+import "testing"
+
+func TestParseCSPLevel(t *testing.T) {
+	for _, level := range []CSPLevel{CSPLevelAny, CSPLevel2, CSPLevel3} {
+		parsed, err := ParseCSPLevel(level.String())
+		if err != nil || parsed != level {
+			t.Errorf("expected %v, got %v, %v", level, parsed, err)
+		}
+	}
+	if _, err := ParseCSPLevel("1"); err == nil {
+		t.Errorf("expected an error for an unsupported level")
+	}
+}
+
+func TestLevelWarnings(t *testing.T) {
+	scriptSrc := ScriptSrc{
+		StrictDynamic:      true,
+		ReportSample:       true,
+		Others:             []string{"'unsafe-hashes'"},
+		EventHandlerHashes: []string{"sha512-a"},
+	}
+	if warnings := scriptSrc.LevelWarnings(); len(warnings) != 0 {
+		t.Errorf("expected no warnings without a target level, got %q", warnings)
+	}
+	scriptSrc.TargetLevel = CSPLevel2
+	if warnings := scriptSrc.LevelWarnings(); len(warnings) != 4 {
+		t.Errorf("expected 4 warnings targeting CSP Level 2, got %q", warnings)
+	}
+	scriptSrc.TargetLevel = CSPLevel3
+	if warnings := scriptSrc.LevelWarnings(); len(warnings) != 0 {
+		t.Errorf("expected no warnings targeting CSP Level 3, got %q", warnings)
+	}
+
+	scriptSrc = ScriptSrc{Self: true, TargetLevel: CSPLevel2}
+	if warnings := scriptSrc.LevelWarnings(); len(warnings) != 0 {
+		t.Errorf("expected no warnings for a CSP Level 2 policy, got %q", warnings)
+	}
+}
+
+func TestOptimizeTargetLevel(t *testing.T) {
+	scriptSrc := ScriptSrc{
+		StrictDynamic: true,
+		Self:          true,
+		Hosts:         []string{"https://cdn.example.com"},
+		Others:        []string{"'nonce-abc'", "https:"},
+		TargetLevel:   CSPLevel3,
+	}
+	notes := scriptSrc.Optimize()
+	if len(notes) != 3 {
+		t.Errorf("expected 3 notes, got %q", notes)
+	}
+	if expected := "'strict-dynamic' 'nonce-abc'"; scriptSrc.String() != expected {
+		t.Errorf("expected %v, got %v", expected, &scriptSrc)
+	}
+}
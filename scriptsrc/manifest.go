@@ -0,0 +1,122 @@
+package scriptsrc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ManifestEntry is a script listed in a build manifest.
+type ManifestEntry struct {
+	// Src is the URL the script is loaded from, either absolute, such as
+	// https://cdn.example.com/assets/main-4f2a.js, or relative to the site, such as
+	// /assets/main-4f2a.js.
+	Src string
+
+	// Integrity is the optional value of the script's integrity attribute, such as sha384-...
+	Integrity string
+}
+
+// ManifestExtractor extracts the scripts from the contents of a build manifest.
+type ManifestExtractor func(data []byte) ([]ManifestEntry, error)
+
+// ExtractManifest is the default ManifestExtractor, which understands the manifests written by
+// common build tools, such as Vite and webpack-assets-manifest.
+//
+// The manifest must be a JSON object, and each value is either the script's URL, as a string, or
+// an object with the URL in a "file" or "src" field, and an optional "integrity" field. For
+// example:
+//
+//	{
+//		"main.js": "/assets/main-4f2a.js",
+//		"index.html": {"file": "assets/index-b81c.js", "isEntry": true},
+//		"vendor.js": {"src": "https://cdn.example.com/vendor-19de.js", "integrity": "sha384-..."}
+//	}
+//
+// Entries that aren't scripts, going by a .js, .mjs or .cjs extension, such as stylesheets, are
+// skipped. Entries are returned in key order.
+func ExtractManifest(data []byte) ([]ManifestEntry, error) {
+	var manifest map[string]json.RawMessage
+	err := json.Unmarshal(data, &manifest)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(manifest))
+	for key := range manifest {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	entries := make([]ManifestEntry, 0, len(keys))
+	for _, key := range keys {
+		var entry ManifestEntry
+		err := json.Unmarshal(manifest[key], &entry.Src)
+		if err != nil {
+			var object struct {
+				File      string `json:"file"`
+				Src       string `json:"src"`
+				Integrity string `json:"integrity"`
+			}
+			err := json.Unmarshal(manifest[key], &object)
+			if err != nil {
+				return nil, fmt.Errorf("failed to understand manifest entry %q: %w", key, err)
+			}
+			entry.Src = object.File
+			if entry.Src == "" {
+				entry.Src = object.Src
+			}
+			entry.Integrity = object.Integrity
+		}
+		if isScriptPath(entry.Src) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// isScriptPath reports whether src, a URL, has a JavaScript file extension.
+func isScriptPath(src string) bool {
+	src, _, _ = strings.Cut(src, "#")
+	src, _, _ = strings.Cut(src, "?")
+	switch strings.ToLower(path.Ext(src)) {
+	case ".js", ".mjs", ".cjs":
+		return true
+	}
+	return false
+}
+
+// AddFromManifest adds the sources required to load the scripts listed in a build manifest, read
+// from r, for scripts that are added to pages dynamically, or by templated paths that aren't
+// present in the HTML.
+//
+// The scripts are extracted using extract, or ExtractManifest if extract is nil. Each script's
+// host is added, as if it were found in a script src, and, when IntegrityHashes and StrictDynamic
+// are set, so are its integrity hashes.
+func (scriptSrc *ScriptSrc) AddFromManifest(r io.Reader, extract ManifestExtractor) error {
+	if err := scriptSrc.checkSealed("AddFromManifest"); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read manifest: %w", err)
+	}
+	if extract == nil {
+		extract = ExtractManifest
+	}
+	entries, err := extract(data)
+	if err != nil {
+		return fmt.Errorf("failed to extract scripts from manifest: %w", err)
+	}
+	for _, entry := range entries {
+		err := scriptSrc.addFoundSrc("script", entry.Src)
+		if err != nil {
+			return err
+		}
+		if entry.Integrity != "" && scriptSrc.IntegrityHashes && scriptSrc.StrictDynamic {
+			scriptSrc.addIntegrityValue(entry.Integrity)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package scriptsrc
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestAddFromManifest(t *testing.T) {
+	const manifest = `{
+		"main.js": "/assets/main-4f2a.js",
+		"index.html": {"file": "assets/index-b81c.js", "isEntry": true, "css": ["assets/index-77e0.css"]},
+		"index.css": {"file": "assets/index-77e0.css"},
+		"vendor.js": {"src": "https://cdn.example.com/vendor-19de.js", "integrity": "sha384-oqVuAfXRKap7fdgcCY5uykM6+R9GqQ8K/uxy9rx7HNQlGYl1kPzQho1wx4JwY8wC"}
+	}`
+	tests := []struct {
+		opts     []Option
+		expected string
+	}{
+		{nil, "'self' https://cdn.example.com"},
+		{[]Option{WithIntegrityHashes(true)}, "'self' https://cdn.example.com"},
+		{
+			[]Option{WithIntegrityHashes(true), WithStrictDynamic(true)},
+			"'self' 'strict-dynamic' 'sha384-oqVuAfXRKap7fdgcCY5uykM6+R9GqQ8K/uxy9rx7HNQlGYl1kPzQho1wx4JwY8wC' https://cdn.example.com",
+		},
+	}
+	for _, test := range tests {
+		scriptSrc := New(test.opts...)
+		err := scriptSrc.AddFromManifest(strings.NewReader(manifest), nil)
+		if err != nil {
+			t.Error(err)
+		} else if got := scriptSrc.String(); got != test.expected {
+			t.Errorf("expected %v, got %v", test.expected, got)
+		}
+	}
+
+	if err := New().AddFromManifest(strings.NewReader(`["main.js"]`), nil); err == nil {
+		t.Error("expected an error for a manifest that isn't an object")
+	}
+
+	extract := func(data []byte) ([]ManifestEntry, error) {
+		var entries []ManifestEntry
+		for _, line := range strings.Fields(string(data)) {
+			entries = append(entries, ManifestEntry{Src: line})
+		}
+		return entries, nil
+	}
+	scriptSrc := New()
+	err := scriptSrc.AddFromManifest(strings.NewReader("https://a.example.com/a.js\nhttps://b.example.com/b.js"), extract)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"https://a.example.com", "https://b.example.com"}; !slices.Equal(scriptSrc.Hosts, expected) {
+		t.Errorf("expected hosts %v from the custom extractor, got %v", expected, scriptSrc.Hosts)
+	}
+}
@@ -0,0 +1,141 @@
+package scriptsrc
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// hasHashesOrNonces reports whether scriptSrc has any hash or nonce sources.
+func (scriptSrc *ScriptSrc) hasHashesOrNonces() bool {
+	return len(scriptSrc.Hashes) > 0 || slices.ContainsFunc(scriptSrc.Others, func(src string) bool {
+		return strings.HasPrefix(strings.ToLower(strings.TrimSpace(src)), "'nonce-")
+	})
+}
+
+// NeedsUnsafeInlineFallback reports whether scriptSrc relies on hashes or nonces to allow inline
+// scripts, without 'unsafe-inline' as a fallback.
+//
+// Browsers only supporting CSP Level 1 ignore hashes and nonces, so block those inline scripts
+// unless 'unsafe-inline' is added. Browsers supporting hashes and nonces ignore 'unsafe-inline'
+// alongside them, so adding it doesn't weaken the policy for them.
+func (scriptSrc *ScriptSrc) NeedsUnsafeInlineFallback() bool {
+	if !scriptSrc.hasHashesOrNonces() {
+		return false
+	}
+	return !slices.ContainsFunc(scriptSrc.Others, func(src string) bool {
+		return strings.EqualFold(strings.TrimSpace(src), "'unsafe-inline'")
+	})
+}
+
+// hostCoveredBy returns the source in covering, other than host itself, that already allows
+// everything host does, or "" if there isn't one.
+//
+// A scheme source, such as https:, covers every host with that scheme, and a wildcard host, such
+// as https://*.example.com, covers every subdomain of example.com with the same scheme and port.
+func hostCoveredBy(host string, covering []string) string {
+	u, err := url.Parse(host)
+	if err != nil || u.Scheme == "" || u.Path != "" {
+		return ""
+	}
+	for _, src := range covering {
+		if src == host {
+			continue
+		}
+		if schemeSourcePattern.MatchString(src) {
+			if strings.EqualFold(strings.TrimSuffix(src, ":"), u.Scheme) {
+				return src
+			}
+			continue
+		}
+		wildcard, err := url.Parse(src)
+		if err != nil || wildcard.Path != "" || !strings.EqualFold(wildcard.Scheme, u.Scheme) || wildcard.Port() != u.Port() {
+			continue
+		}
+		domain, ok := strings.CutPrefix(wildcard.Hostname(), "*.")
+		if ok && strings.HasSuffix(strings.ToLower(u.Hostname()), "."+strings.ToLower(domain)) {
+			return src
+		}
+	}
+	return ""
+}
+
+// Optimize removes sources that are redundant, so the policy is as small as possible while
+// allowing exactly the same scripts, and returns a description of each change, along with notes
+// about sources that browsers ignore, but are kept.
+//
+// This assumes browsers support at least CSP Level 2, which every current browser does:
+//   - 'unsafe-inline' is removed if there are any hashes or nonces, since browsers supporting
+//     them ignore it. It only has an effect in browsers that only support CSP Level 1, if at all.
+//   - 'none' is removed if there are any other sources, since it's ignored alongside them.
+//   - Hosts already allowed by a scheme source in Others, such as https:, or by a wildcard host,
+//     such as https://*.example.com, are removed.
+//
+// Under 'strict-dynamic', browsers supporting CSP Level 3 ignore 'self', hosts and scheme sources.
+// These are noted, but kept, since browsers without 'strict-dynamic' support still need them,
+// unless TargetLevel is [CSPLevel3], in which case they're removed.
+//
+// If browsers only supporting CSP Level 1 must run the inline scripts, don't use Optimize, see
+// [ScriptSrc.NeedsUnsafeInlineFallback].
+func (scriptSrc *ScriptSrc) Optimize() []string {
+	scriptSrc.panicIfSealed("Optimize")
+	var notes []string
+
+	if scriptSrc.hasHashesOrNonces() {
+		scriptSrc.Others = slices.DeleteFunc(scriptSrc.Others, func(src string) bool {
+			if strings.EqualFold(strings.TrimSpace(src), "'unsafe-inline'") {
+				notes = append(notes, "removed 'unsafe-inline', which is ignored by browsers supporting hashes and nonces")
+				return true
+			}
+			return false
+		})
+	}
+
+	isNone := func(src string) bool {
+		return strings.EqualFold(strings.TrimSpace(src), "'none'")
+	}
+	if slices.ContainsFunc(scriptSrc.Others, isNone) && len(scriptSrc.Sources()) > 1 {
+		scriptSrc.Others = slices.DeleteFunc(scriptSrc.Others, isNone)
+		notes = append(notes, "removed 'none', which is ignored alongside other sources")
+	}
+
+	var schemes []string
+	for _, src := range scriptSrc.Others {
+		if src = strings.TrimSpace(src); schemeSourcePattern.MatchString(src) {
+			schemes = append(schemes, src)
+		}
+	}
+	covering := append(slices.Clone(schemes), scriptSrc.Hosts...)
+	scriptSrc.Hosts = slices.DeleteFunc(scriptSrc.Hosts, func(host string) bool {
+		if by := hostCoveredBy(host, covering); by != "" {
+			notes = append(notes, fmt.Sprintf("removed %v, which is already allowed by %v", host, by))
+			return true
+		}
+		return false
+	})
+
+	if scriptSrc.StrictDynamic && scriptSrc.TargetLevel == CSPLevel3 {
+		if scriptSrc.includesSelf() {
+			scriptSrc.SelfMode = SelfNever
+			notes = append(notes, "removed 'self', which is ignored by browsers supporting 'strict-dynamic'")
+		}
+		for _, host := range scriptSrc.Hosts {
+			notes = append(notes, fmt.Sprintf("removed %v, which is ignored by browsers supporting 'strict-dynamic'", host))
+		}
+		scriptSrc.Hosts = nil
+		scriptSrc.Others = slices.DeleteFunc(scriptSrc.Others, func(src string) bool {
+			if src = strings.TrimSpace(src); schemeSourcePattern.MatchString(src) {
+				notes = append(notes, fmt.Sprintf("removed %v, which is ignored by browsers supporting 'strict-dynamic'", src))
+				return true
+			}
+			return false
+		})
+	} else if scriptSrc.StrictDynamic && (scriptSrc.includesSelf() || len(scriptSrc.Hosts) > 0 || len(schemes) > 0) {
+		notes = append(notes, fmt.Sprintf(
+			"browsers supporting 'strict-dynamic' ignore %v, but they're kept for browsers that don't",
+			ignoredSources(scriptSrc.includesSelf(), len(scriptSrc.Hosts), len(schemes)),
+		))
+	}
+	return notes
+}
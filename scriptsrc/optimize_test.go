@@ -0,0 +1,82 @@
+package scriptsrc
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestHostCoveredBy(t *testing.T) {
+	covering := []string{"https://*.example.com", "wss:", "https://*.example.org:8443"}
+	tests := []struct {
+		host     string
+		expected string
+	}{
+		{"https://cdn.example.com", "https://*.example.com"},
+		{"https://a.b.example.com", "https://*.example.com"},
+		{"https://example.com", ""},
+		{"https://cdn.example.com:8443", ""},
+		{"wss://socket.example.net", "wss:"},
+		{"https://cdn.example.org:8443", "https://*.example.org:8443"},
+		{"https://cdn.example.org", ""},
+		{"https://*.example.com", ""},
+		{"https://notexample.com", ""},
+	}
+	for _, test := range tests {
+		if got := hostCoveredBy(test.host, covering); got != test.expected {
+			t.Errorf("expected %q for %v, got %q", test.expected, test.host, got)
+		}
+	}
+}
+
+func TestOptimize(t *testing.T) {
+	scriptSrc := ScriptSrc{
+		Self:   true,
+		Hosts:  []string{"https://*.example.com", "https://cdn.example.com", "https://other.com"},
+		Others: []string{"'unsafe-inline'", "'none'", "'unsafe-eval'"},
+	}
+	scriptSrc.AddInline("a")
+	notes := scriptSrc.Optimize()
+	if len(notes) != 3 {
+		t.Errorf("expected 3 notes, got %q", notes)
+	}
+	expected := ScriptSrc{
+		Self:   true,
+		Hosts:  []string{"https://*.example.com", "https://other.com"},
+		Others: []string{"'unsafe-eval'"},
+	}
+	expected.AddInline("a")
+	if scriptSrc.String() != expected.String() {
+		t.Errorf("expected %v, got %v", &expected, &scriptSrc)
+	}
+
+	// Without hashes or nonces, 'unsafe-inline' is required, and a lone 'none' is meaningful.
+	for _, others := range [][]string{{"'unsafe-inline'"}, {"'none'"}} {
+		scriptSrc := ScriptSrc{Others: slices.Clone(others)}
+		if notes := scriptSrc.Optimize(); len(notes) != 0 || !slices.Equal(scriptSrc.Others, others) {
+			t.Errorf("expected %v to be unchanged, got %v with notes %q", others, scriptSrc.Others, notes)
+		}
+	}
+
+	scriptSrc = ScriptSrc{StrictDynamic: true, Self: true, Others: []string{"'nonce-abc'", "'unsafe-inline'", "https:"}}
+	notes = scriptSrc.Optimize()
+	if len(notes) != 2 || !slices.Equal(scriptSrc.Others, []string{"'nonce-abc'", "https:"}) {
+		t.Errorf("expected 'unsafe-inline' to be removed, and a note about 'strict-dynamic', got %v with notes %q", &scriptSrc, notes)
+	}
+}
+
+func TestNeedsUnsafeInlineFallback(t *testing.T) {
+	tests := []struct {
+		scriptSrc ScriptSrc
+		expected  bool
+	}{
+		{ScriptSrc{Self: true}, false},
+		{ScriptSrc{Hashes: []string{"sha256-ypeBEsobvcr6wjGzmiPcTaeG7/gUfE5yuYB3ha/uSLs="}}, true},
+		{ScriptSrc{Others: []string{"'nonce-abc'"}}, true},
+		{ScriptSrc{Others: []string{"'nonce-abc'", "'unsafe-inline'"}}, false},
+	}
+	for _, test := range tests {
+		if got := test.scriptSrc.NeedsUnsafeInlineFallback(); got != test.expected {
+			t.Errorf("expected %v for %v, got %v", test.expected, &test.scriptSrc, got)
+		}
+	}
+}
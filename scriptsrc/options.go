@@ -0,0 +1,129 @@
+package scriptsrc
+
+// Option configures a ScriptSrc created with [New].
+type Option func(*ScriptSrc)
+
+// New creates a ScriptSrc configured with the provided options.
+//
+// The zero value ScriptSrc remains valid to use directly, New just provides a stable way to
+// configure one without setting fields.
+func New(opts ...Option) *ScriptSrc {
+	scriptSrc := &ScriptSrc{}
+	for _, opt := range opts {
+		opt(scriptSrc)
+	}
+	return scriptSrc
+}
+
+// WithHashAlgorithm sets the DefaultHashAlgorithm used for hashing inline scripts.
+func WithHashAlgorithm(alg HashAlgorithm) Option {
+	return func(scriptSrc *ScriptSrc) {
+		scriptSrc.DefaultHashAlgorithm = alg
+	}
+}
+
+// WithHashEncoding sets the HashEncoding used for hashing inline scripts.
+//
+// Browsers expect [StdBase64], the default, see [URLBase64].
+func WithHashEncoding(encoding HashEncoding) Option {
+	return func(scriptSrc *ScriptSrc) {
+		scriptSrc.HashEncoding = encoding
+	}
+}
+
+// WithKeepContents sets whether the content of inline scripts is kept, see ScriptSrc.KeepContents.
+func WithKeepContents(keepContents bool) Option {
+	return func(scriptSrc *ScriptSrc) {
+		scriptSrc.KeepContents = keepContents
+	}
+}
+
+// WithNormalizeLineEndings sets whether CRLF line endings are normalized before hashing, see
+// ScriptSrc.NormalizeLineEndings.
+func WithNormalizeLineEndings(normalize bool) Option {
+	return func(scriptSrc *ScriptSrc) {
+		scriptSrc.NormalizeLineEndings = normalize
+	}
+}
+
+// WithIncludeConnectionHints sets whether the hosts of preconnect and dns-prefetch links are
+// included, see ScriptSrc.IncludeConnectionHints.
+func WithIncludeConnectionHints(include bool) Option {
+	return func(scriptSrc *ScriptSrc) {
+		scriptSrc.IncludeConnectionHints = include
+	}
+}
+
+// WithIntegrityHashes sets whether the integrity hashes of external scripts are added under
+// 'strict-dynamic', see ScriptSrc.IntegrityHashes.
+func WithIntegrityHashes(integrityHashes bool) Option {
+	return func(scriptSrc *ScriptSrc) {
+		scriptSrc.IntegrityHashes = integrityHashes
+	}
+}
+
+// WithErrorOnInsecure sets whether scripts with an http src are errors, see
+// ScriptSrc.ErrorOnInsecure.
+func WithErrorOnInsecure(errorOnInsecure bool) Option {
+	return func(scriptSrc *ScriptSrc) {
+		scriptSrc.ErrorOnInsecure = errorOnInsecure
+	}
+}
+
+// WithHostNormalizer sets the function applied to the host of each src, see
+// ScriptSrc.HostNormalizer.
+func WithHostNormalizer(normalize func(host string) string) Option {
+	return func(scriptSrc *ScriptSrc) {
+		scriptSrc.HostNormalizer = normalize
+	}
+}
+
+// WithNoHosts sets whether srcs that require a host source are errors, see ScriptSrc.NoHosts.
+func WithNoHosts(noHosts bool) Option {
+	return func(scriptSrc *ScriptSrc) {
+		scriptSrc.NoHosts = noHosts
+	}
+}
+
+// WithTargetLevel sets the CSP level of the browsers the policy is for, see ScriptSrc.TargetLevel.
+func WithTargetLevel(level CSPLevel) Option {
+	return func(scriptSrc *ScriptSrc) {
+		scriptSrc.TargetLevel = level
+	}
+}
+
+// WithStrictDynamic sets whether 'strict-dynamic' is included.
+func WithStrictDynamic(strictDynamic bool) Option {
+	return func(scriptSrc *ScriptSrc) {
+		scriptSrc.StrictDynamic = strictDynamic
+	}
+}
+
+// WithReportSample sets whether 'report-sample' is included, see ScriptSrc.ReportSample.
+func WithReportSample(reportSample bool) Option {
+	return func(scriptSrc *ScriptSrc) {
+		scriptSrc.ReportSample = reportSample
+	}
+}
+
+// WithSelf sets whether 'self' is included, regardless of whether any relative script sources are
+// found.
+func WithSelf(self bool) Option {
+	return func(scriptSrc *ScriptSrc) {
+		scriptSrc.Self = self
+	}
+}
+
+// WithSelfMode sets the SelfMode, which overrides whether 'self' is included.
+func WithSelfMode(mode SelfMode) Option {
+	return func(scriptSrc *ScriptSrc) {
+		scriptSrc.SelfMode = mode
+	}
+}
+
+// WithMaxBytes sets the MaxBytes checked by [ScriptSrc.CheckSize].
+func WithMaxBytes(maxBytes int) Option {
+	return func(scriptSrc *ScriptSrc) {
+		scriptSrc.MaxBytes = maxBytes
+	}
+}
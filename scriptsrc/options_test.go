@@ -0,0 +1,54 @@
+package scriptsrc
+
+import "testing"
+
+func TestNew(t *testing.T) {
+	scriptSrc := New(WithHashAlgorithm(Sha256), WithStrictDynamic(true), WithSelf(true))
+	if scriptSrc.DefaultHashAlgorithm != Sha256 {
+		t.Errorf("expected Sha256, got %v", scriptSrc.DefaultHashAlgorithm)
+	}
+	scriptSrc.AddInline("a")
+	expected := "'self' 'strict-dynamic' 'sha256-ypeBEsobvcr6wjGzmiPcTaeG7/gUfE5yuYB3ha/uSLs='"
+	if got := scriptSrc.String(); got != expected {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	scriptSrc = New(WithReportSample(true), WithStrictDynamic(true))
+	scriptSrc.AddInline("a")
+	expected = "'strict-dynamic' 'report-sample' 'sha512-H0D8ktokFpR1CXnubPWC8tXX0o4YM13gWrxU0FYOD1MChgxlK/CNVgJSql50IQVG82n7u86MEs/HlXsmUv6adQ=='"
+	if got := scriptSrc.String(); got != expected {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	if got := New().String(); got != "'none'" {
+		t.Errorf("expected 'none' for an empty script-src, got %v", got)
+	}
+}
+
+func TestSelfMode(t *testing.T) {
+	tests := []struct {
+		mode     string
+		self     bool
+		expected string
+	}{
+		{"auto", false, "https://example.com"},
+		{"auto", true, "'self' https://example.com"},
+		{"always", false, "'self' https://example.com"},
+		{"never", true, "https://example.com"},
+	}
+	for _, test := range tests {
+		mode, err := ParseSelfMode(test.mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mode.String() != test.mode {
+			t.Errorf("expected %v to round trip, got %v", test.mode, mode)
+		}
+		scriptSrc := New(WithSelfMode(mode), WithSelf(test.self))
+		scriptSrc.Hosts = []string{"https://example.com"}
+		if got := scriptSrc.String(); got != test.expected {
+			t.Errorf("expected %v for %v with Self %v, got %v", test.expected, test.mode, test.self, got)
+		}
+	}
+	if _, err := ParseSelfMode("sometimes"); err == nil {
+		t.Errorf("expected an error for an unknown mode")
+	}
+}
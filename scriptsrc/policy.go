@@ -0,0 +1,216 @@
+package scriptsrc
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// SourceList is a simple list of sources, for directives other than script-src, that are collected
+// from src attributes.
+type SourceList struct {
+	// Self indicates if 'self' should be included.
+	Self bool
+
+	// Hosts are the host sources, such as https://example.com
+	Hosts []string
+
+	// Others are strings, to be added exactly as they appear.
+	Others []string
+}
+
+// AddSrc adds either 'self' or the required host entry to sourceList to allow the provided source
+// to be loaded.
+//
+// This function returns an error if the src is http, not https.
+func (sourceList *SourceList) AddSrc(srcString string) error {
+	host, err := hostSource("", srcString)
+	if err != nil {
+		return err
+	}
+	if host == "" {
+		sourceList.Self = true
+	} else {
+		sourceList.Hosts = appendUnique(sourceList.Hosts, host)
+	}
+	return nil
+}
+
+// String formats sourceList as it should appear in a Content-Security-Policy header value, after
+// the directive name.
+func (sourceList *SourceList) String() string {
+	srcs := make([]string, 0, 1+len(sourceList.Hosts)+len(sourceList.Others))
+	if sourceList.Self {
+		srcs = append(srcs, "'self'")
+	}
+	srcs = append(srcs, sourceList.Hosts...)
+	srcs = append(srcs, sourceList.Others...)
+	return strings.Join(srcs, " ")
+}
+
+// Policy collects multiple directives of a Content Security Policy from HTML, using a single walk of
+// the document.
+//
+// The zero value is an empty policy, ready to use.
+type Policy struct {
+	// ScriptSrc is the script-src directive, collected exactly as [ScriptSrc.AddFromHTML] does.
+	ScriptSrc ScriptSrc
+
+	// FrameSrc is the frame-src directive, collected from the src attributes of iframe and frame
+	// elements.
+	FrameSrc SourceList
+}
+
+// addFrameSrc adds the src of the frame element n, such as an iframe, to policy.FrameSrc.
+func (policy *Policy) addFrameSrc(n *html.Node) (bool, error) {
+	for _, attr := range srcAttrs(n) {
+		// As with script srcs, srcs that can't be allowed are skipped.
+		policy.FrameSrc.AddSrc(attr.Val)
+	}
+	return false, nil
+}
+
+// policyElements are the handlers for elements that require sources for directives other than
+// script-src.
+var policyElements = []elementHandler[*Policy]{
+	{"iframe", (*Policy).addFrameSrc},
+	{"frame", (*Policy).addFrameSrc},
+}
+
+// visitElement collects the sources for directives other than script-src from n.
+func (policy *Policy) visitElement(n *html.Node) error {
+	if handler := lookupElementHandler(policyElements, n); handler != nil {
+		_, err := handler.handle(policy, n)
+		return err
+	}
+	return nil
+}
+
+// AddFromHTML adds the sources required to load everything recursively within the node.
+//
+// See [ScriptSrc.AddFromHTML] for details of how scripts are handled, including the error returned
+// if DefaultHashAlgorithm or HashEncoding is invalid.
+func (policy *Policy) AddFromHTML(n *html.Node, includeEventHandlers bool) error {
+	if err := policy.ScriptSrc.checkSealed("Policy.AddFromHTML"); err != nil {
+		return err
+	}
+	if err := checkHashConfig(policy.ScriptSrc.DefaultHashAlgorithm, policy.ScriptSrc.HashEncoding); err != nil {
+		return err
+	}
+	return policy.ScriptSrc.addFromHTML(n, includeEventHandlers, policy.visitElement)
+}
+
+// AddFromHTMLReader parses r as HTML, and then calls policy.AddFromHTML with the result.
+func (policy *Policy) AddFromHTMLReader(r io.Reader, includeEventHandlers bool) error {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return fmt.Errorf("failed to parse HTML: %w", err)
+	}
+	return policy.AddFromHTML(doc, includeEventHandlers)
+}
+
+// AddFromHTMLFile parses the file from path, as HTML, and then calls policy.AddFromHTML with the
+// result. Gzipped files are handled as in [ScriptSrc.AddFromHTMLFile].
+func (policy *Policy) AddFromHTMLFile(path string, includeEventHandlers bool) error {
+	doc, err := parseHTMLFile(path)
+	if err != nil {
+		return err
+	}
+	err = policy.AddFromHTML(doc, includeEventHandlers)
+	if err != nil {
+		return fmt.Errorf("failed to process %v: %w", path, err)
+	}
+	return nil
+}
+
+// String formats policy as a Content-Security-Policy header value, for example:
+//
+//	script-src 'self'; frame-src https://www.youtube.com
+//
+// Directives for which no sources were found are omitted, other than script-src which is always
+// included.
+func (policy *Policy) String() string {
+	directives := []string{"script-src " + policy.ScriptSrc.String()}
+	if frameSrc := policy.FrameSrc.String(); frameSrc != "" {
+		directives = append(directives, "frame-src "+frameSrc)
+	}
+	return strings.Join(directives, "; ")
+}
+
+// HeaderOptions configures the directives included by [BuildHeader], in addition to script-src.
+type HeaderOptions struct {
+	// DefaultSrc is the value of the default-src directive. If empty, 'self' is used, unless
+	// NoDefaults is set.
+	DefaultSrc string
+
+	// ObjectSrc is the value of the object-src directive. If empty, 'none' is used, unless
+	// NoDefaults is set.
+	ObjectSrc string
+
+	// NoDefaults omits default-src and object-src, unless they are set explicitly.
+	NoDefaults bool
+
+	// FrameSrc, if set and not empty, is included as the frame-src directive.
+	FrameSrc *SourceList
+
+	// RequireTrustedTypes adds the directive require-trusted-types-for 'script', which makes
+	// browsers that support Trusted Types reject strings passed to DOM XSS sinks, such as innerHTML,
+	// unless they come from a Trusted Types policy.
+	//
+	// This is independent of the collected script-src, which is unaffected by it, but it's
+	// a useful addition to a hash-based policy, since it protects against DOM-based XSS that
+	// script-src alone can't.
+	RequireTrustedTypes bool
+
+	// Directives are extra directives, such as "style-src 'self'", added exactly as given after all
+	// the others.
+	Directives []string
+}
+
+// BuildHeader returns a complete Content-Security-Policy header value, containing the script-src
+// directive from scriptSrc, along with sensible defaults for other directives, for example:
+//
+//	default-src 'self'; script-src 'self' https://challenges.cloudflare.com; object-src 'none'
+//
+// See [HeaderOptions] for how to configure the other directives.
+func BuildHeader(scriptSrc *ScriptSrc, opts HeaderOptions) string {
+	defaultSrc, objectSrc := opts.DefaultSrc, opts.ObjectSrc
+	if !opts.NoDefaults {
+		if defaultSrc == "" {
+			defaultSrc = "'self'"
+		}
+		if objectSrc == "" {
+			objectSrc = "'none'"
+		}
+	}
+
+	var directives []string
+	if defaultSrc != "" {
+		directives = append(directives, "default-src "+defaultSrc)
+	}
+	directives = append(directives, "script-src "+scriptSrc.String())
+	if objectSrc != "" {
+		directives = append(directives, "object-src "+objectSrc)
+	}
+	if opts.FrameSrc != nil {
+		if frameSrc := opts.FrameSrc.String(); frameSrc != "" {
+			directives = append(directives, "frame-src "+frameSrc)
+		}
+	}
+	if opts.RequireTrustedTypes {
+		directives = append(directives, "require-trusted-types-for 'script'")
+	}
+	directives = append(directives, opts.Directives...)
+	return strings.Join(directives, "; ")
+}
+
+// Header returns a complete Content-Security-Policy header value for policy, as [BuildHeader] does,
+// using the collected FrameSrc unless opts.FrameSrc is set.
+func (policy *Policy) Header(opts HeaderOptions) string {
+	if opts.FrameSrc == nil {
+		opts.FrameSrc = &policy.FrameSrc
+	}
+	return BuildHeader(&policy.ScriptSrc, opts)
+}
@@ -0,0 +1,69 @@
+package scriptsrc
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPolicy(t *testing.T) {
+	const page = `<!DOCTYPE html>
+<script src="/app.js"></script>
+<iframe src="https://www.youtube.com/embed/x"></iframe>
+<iframe src="/embed.html"></iframe>
+<iframe src="http://insecure.example.com"></iframe>`
+
+	var policy Policy
+	err := policy.AddFromHTMLReader(strings.NewReader(page), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "script-src 'self'; frame-src 'self' https://www.youtube.com"
+	if got := policy.String(); got != expected {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	policy = Policy{}
+	err = policy.AddFromHTMLFile("./tests/just-self.html", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := policy.String(); got != "script-src 'self'" {
+		t.Errorf("expected script-src 'self', got %v", got)
+	}
+}
+
+func TestBuildHeader(t *testing.T) {
+	scriptSrc := &ScriptSrc{Self: true, Hosts: []string{"https://challenges.cloudflare.com"}}
+	tests := []struct {
+		opts     HeaderOptions
+		expected string
+	}{
+		{
+			HeaderOptions{},
+			"default-src 'self'; script-src 'self' https://challenges.cloudflare.com; object-src 'none'",
+		},
+		{
+			HeaderOptions{DefaultSrc: "'none'", Directives: []string{"style-src 'self'"}},
+			"default-src 'none'; script-src 'self' https://challenges.cloudflare.com; object-src 'none'; style-src 'self'",
+		},
+		{
+			HeaderOptions{RequireTrustedTypes: true},
+			"default-src 'self'; script-src 'self' https://challenges.cloudflare.com; object-src 'none'; require-trusted-types-for 'script'",
+		},
+		{
+			HeaderOptions{NoDefaults: true, FrameSrc: &SourceList{Hosts: []string{"https://www.youtube.com"}}},
+			"script-src 'self' https://challenges.cloudflare.com; frame-src https://www.youtube.com",
+		},
+	}
+	for _, test := range tests {
+		if got := BuildHeader(scriptSrc, test.opts); got != test.expected {
+			t.Errorf("expected %v, got %v", test.expected, got)
+		}
+	}
+
+	policy := Policy{ScriptSrc: *scriptSrc, FrameSrc: SourceList{Self: true}}
+	expected := "default-src 'self'; script-src 'self' https://challenges.cloudflare.com; object-src 'none'; frame-src 'self'"
+	if got := policy.Header(HeaderOptions{}); got != expected {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
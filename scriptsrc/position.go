@@ -0,0 +1,93 @@
+package scriptsrc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// scriptError is an error adding the script element node, so its position in the source can be
+// found if the source is known.
+type scriptError struct {
+	node *html.Node
+	err  error
+}
+
+func (err *scriptError) Error() string {
+	return err.err.Error()
+}
+
+func (err *scriptError) Unwrap() error {
+	return err.err
+}
+
+// withPosition returns err, with the line and column of the script it's about appended, if it's a
+// scriptError, and the script can be found in data, the source that doc was parsed from.
+func withPosition(err error, doc *html.Node, data []byte) error {
+	var scriptErr *scriptError
+	if !errors.As(err, &scriptErr) {
+		return err
+	}
+	line, column, ok := scriptPosition(doc, scriptErr.node, data)
+	if !ok {
+		return err
+	}
+	return fmt.Errorf("%w at line %v, column %v", err, line, column)
+}
+
+// scriptPosition returns the line and column, both starting at 1, of the start tag of the script
+// element n within data, the source that doc was parsed from.
+//
+// The parser doesn't record positions, so the script elements in doc are counted to find the
+// index of n, and data is then tokenized to find the start tag of the script with the same index.
+// Scripts added to the document other than by parsing, for example, would make this inaccurate.
+func scriptPosition(doc, n *html.Node, data []byte) (line, column int, ok bool) {
+	index, found := scriptIndex(doc, n, 0)
+	if !found {
+		return 0, 0, false
+	}
+	z := html.NewTokenizer(bytes.NewReader(data))
+	offset := 0
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return 0, 0, false
+		}
+		raw := len(z.Raw())
+		if tt == html.StartTagToken || tt == html.SelfClosingTagToken {
+			name, _ := z.TagName()
+			if strings.EqualFold(string(name), "script") {
+				if index == 0 {
+					before := data[:offset]
+					line = bytes.Count(before, []byte("\n")) + 1
+					column = offset - bytes.LastIndexByte(before, '\n')
+					return line, column, true
+				}
+				index--
+			}
+		}
+		offset += raw
+	}
+}
+
+// scriptIndex returns the number of script elements before n in a depth first walk of the tree
+// from node, plus previous, and whether n was found.
+func scriptIndex(node, n *html.Node, previous int) (int, bool) {
+	if node == n {
+		return previous, true
+	}
+	if node.Type == html.ElementNode && strings.EqualFold(node.Data, "script") {
+		previous++
+	}
+	for c := node.FirstChild; c != nil; c = c.NextSibling {
+		var found bool
+		previous, found = scriptIndex(c, n, previous)
+		if found {
+			return previous, true
+		}
+	}
+	return previous, false
+}
@@ -0,0 +1,31 @@
+package scriptsrc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestErrorPosition(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected string
+	}{
+		{"<script></script>", "at line 1, column 1"},
+		{"<p>\n\n  <script>ok()</script><SCRIPT></SCRIPT>", "at line 3, column 24"},
+		{"<!-- <script></script> -->\n<noscript><script></script></noscript>\n<script></script>", "at line 3, column 1"},
+		{"<svg>\n<script/>\n</svg>", "at line 2, column 1"},
+	}
+	for _, test := range tests {
+		path := filepath.Join(t.TempDir(), "test.html")
+		err := os.WriteFile(path, []byte(test.content), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = (&ScriptSrc{}).AddFromHTMLFile(path, false)
+		if err == nil || !strings.HasSuffix(err.Error(), test.expected) {
+			t.Errorf("expected an error ending %q for %q, got %v", test.expected, test.content, err)
+		}
+	}
+}
@@ -48,6 +48,9 @@
 //	script-src-generator --quiet --csp-template-string "Content-Security-Policy: script-src {{ .ScriptSrc }};" /web/root/**.html
 //	> Content-Security-Policy: script-src 'self' 'sha512-...' ... https://challenges.cloudflare.com;
 //
+// Arguments can also be http:// or https:// URLs, in which case the served HTML is fetched and
+// analyzed. As with files, the fetched HTML must be trusted!
+//
 // See script-src-generator --help for more details, including templating support.
 //
 // If go/bin isn't in your path, the command will instead be ~/go/bin/script-src-generator.
@@ -74,19 +77,36 @@
 package scriptsrc
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
 	"crypto/sha256"
 	"crypto/sha512"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"hash"
+	"io"
+	"io/fs"
 	"net/url"
 	"os"
 	"path/filepath"
+	"runtime"
 	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"golang.org/x/net/html"
 )
 
+// HashAlgorithm specifies the algorithm used to hash inline scripts.
+//
+// CSP only defines sha256, sha384 and sha512 hash sources, and browsers ignore hash sources with
+// any other algorithm, so other algorithms, such as SHA-512/256 (the truncation of SHA-512 to 256
+// bits) aren't supported, since a policy using them would block every script it hashes. Use
+// [Sha256] for a hash of the same length.
 type HashAlgorithm uint8
 
 const (
@@ -94,6 +114,66 @@ const (
 	Sha256 HashAlgorithm = 1
 )
 
+// HashEncoding specifies how the digest of a hash source is base64 encoded.
+type HashEncoding uint8
+
+const (
+	// StdBase64 is standard base64 encoding, as required by the CSP specification.
+	StdBase64 HashEncoding = 0
+
+	// URLBase64 is URL-safe base64 encoding.
+	//
+	// Warning: browsers expect standard base64, so hashes encoded like this are only useful for
+	// testing against other tools, and must not be used in a real policy.
+	URLBase64 HashEncoding = 1
+)
+
+// hashEncodings are the base64 encodings for each HashEncoding.
+var hashEncodings = [...]*base64.Encoding{
+	StdBase64: base64.StdEncoding,
+	URLBase64: base64.URLEncoding,
+}
+
+// SelfMode specifies whether 'self' is included in a ScriptSrc.
+type SelfMode uint8
+
+const (
+	// SelfAuto includes 'self' only if ScriptSrc.Self is set, such as when a relative script src is
+	// found.
+	SelfAuto SelfMode = iota
+
+	// SelfAlways always includes 'self', even if no scripts are loaded from the same origin.
+	SelfAlways
+
+	// SelfNever never includes 'self', even if scripts are loaded from the same origin.
+	SelfNever
+)
+
+// selfModeNames are the names of each SelfMode, as used by ParseSelfMode.
+var selfModeNames = [...]string{
+	SelfAuto:   "auto",
+	SelfAlways: "always",
+	SelfNever:  "never",
+}
+
+// String returns the name of the mode, such as "auto".
+func (mode SelfMode) String() string {
+	if int(mode) < len(selfModeNames) {
+		return selfModeNames[mode]
+	}
+	return fmt.Sprintf("SelfMode(%d)", mode)
+}
+
+// ParseSelfMode returns the SelfMode with the given name, one of "auto", "always" or "never".
+func ParseSelfMode(name string) (SelfMode, error) {
+	for mode, modeName := range selfModeNames {
+		if modeName == name {
+			return SelfMode(mode), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown self mode: %v", name)
+}
+
 // ScriptSrc represents a script-src from a Content Security Policy (CSP)
 //
 // See https://developer.mozilla.org/en-US/docs/Web/HTTP/Headers/Content-Security-Policy
@@ -101,6 +181,17 @@ type ScriptSrc struct {
 	// Self indicates if 'self' should be included.
 	Self bool
 
+	// StrictDynamic indicates if 'strict-dynamic' should be included.
+	//
+	// When 'strict-dynamic' is present, browsers that support it ignore host sources and 'self', and
+	// instead trust scripts loaded by already trusted (hashed) scripts.
+	StrictDynamic bool
+
+	// ReportSample indicates if 'report-sample' should be included, so violation reports, sent when
+	// the policy has a report-uri or report-to directive, include the first 40 characters of the
+	// blocked script. It only affects reports, never which scripts are allowed.
+	ReportSample bool
+
 	// Hashes are sha256, sha384 or sha512 hashes of scripts that are allowed to be inline (inside script tags or event handlers).
 	//
 	// The entries in this array should be of the form <hash-algorithm>-<base64-hash>.
@@ -108,16 +199,195 @@ type ScriptSrc struct {
 	// Surrounding quotes will be added when formatted.
 	Hashes []string
 
+	// Labels optionally associates a label with entries of Hashes, such as where the script came
+	// from. The keys are of the same form as Hashes.
+	//
+	// Labels never appear in the output of String, but can be emitted as comments by [ScriptSrc.Render].
+	Labels map[string]string
+
+	// Files optionally lists the files, such as HTML files, that contributed sources, for recording
+	// where the policy came from. Like Labels, they never appear in the output of String, but can be
+	// emitted as comments by [ScriptSrc.Render].
+	Files []string
+
+	// EventHandlerHashes are the entries of Hashes that are hashes of event handler attributes, such
+	// as onclick, rather than script tags.
+	//
+	// Browsers only allow event handlers by hash if 'unsafe-hashes' is also present, see
+	// [ScriptSrc.CheckStrict].
+	EventHandlerHashes []string
+
 	// DefaultHashAlgorithm specified which hashing algorithm is used for generating hashes of inline scripts.
 	//
 	// The zero value for this is [Sha512].
 	DefaultHashAlgorithm HashAlgorithm
 
+	// KeepContents specifies whether the content of each hashed inline script is kept in Contents.
+	//
+	// This is required to rehash scripts later, for example with [ScriptSrc.NormalizeAlgorithm],
+	// but increases memory usage for large sites.
+	KeepContents bool
+
+	// Contents maps entries of Hashes to the content of the script they are the hash of, if
+	// KeepContents was set when they were added.
+	Contents map[string]string
+
+	// HashEncoding specifies how the hashes of inline scripts are base64 encoded.
+	//
+	// The zero value for this is [StdBase64], which is what browsers expect. Only change this for
+	// interoperability testing, see [URLBase64].
+	HashEncoding HashEncoding
+
+	// NormalizeLineEndings replaces "\r\n" with "\n" in content before it's hashed by
+	// [ScriptSrc.AddInline], including external script content, so files checked out with CRLF line
+	// endings hash the same as with LF line endings.
+	//
+	// Inline scripts found by [ScriptSrc.AddFromHTML] don't need this, since the HTML parser already
+	// normalizes line endings, exactly as browsers do. For other content, the browser must receive
+	// LF line endings too, otherwise the hash won't match, so this is off by default.
+	NormalizeLineEndings bool
+
+	// SelfMode overrides whether 'self' is included, regardless of Self.
+	//
+	// The zero value for this is [SelfAuto], which includes 'self' only if Self is set.
+	SelfMode SelfMode
+
 	// Hosts are the host sources, such as https://example.com
 	Hosts []string
 
-	// Others are strings, to be added exactly as they appear (without quotes, but surrounding spaces will be added).
+	// ExternalScripts specifies how scripts with a src attribute are allowed, by their host (the
+	// default), by the hash of their content, or both.
+	//
+	// Hashing external scripts is useful for hash-only policies, but browsers only allow external
+	// scripts by hash if the script tag has a matching integrity attribute (and browsers without
+	// CSP Level 3 support don't allow them by hash at all), so hosts are usually more appropriate.
+	// Hashes also pin the exact content of the script, so they must be regenerated whenever it
+	// changes.
+	ExternalScripts ExternalScripts
+
+	// ErrorOnInsecure makes [ScriptSrc.AddFromHTML] return an error wrapping [ErrInsecure] for
+	// scripts with an http src, instead of adding a warning, so insecure scripts can't be added
+	// unnoticed. Links that fetch scripts with an http href are always errors.
+	ErrorOnInsecure bool
+
+	// IntegrityHashes, when StrictDynamic is also set, adds the hashes in the integrity attribute of
+	// external scripts, such as <script src="..." integrity="sha384-...">, to Hashes.
+	//
+	// Under 'strict-dynamic', browsers ignore hosts, but allow external scripts whose integrity
+	// matches a hash in the policy, so this allows them without hashing their content.
+	IntegrityHashes bool
+
+	// ExternalScriptContent gets the content of external scripts to hash when ExternalScripts isn't
+	// [ExternalHosts]. See [ExternalScriptContentFromFiles], and scriptsrchttp.ExternalScriptContent
+	// to fetch them.
+	//
+	// The content returned must be trusted, exactly as the HTML must be.
+	ExternalScriptContent ExternalScriptContentFunc
+
+	// IsEventHandler, if set, reports whether an attribute, given its key, is an event handler whose
+	// value should be hashed when event handlers are included.
+	//
+	// If nil, [IsEventHandlerAttribute] is used, which only matches standard event handlers.
+	IsEventHandler func(key string) bool
+
+	// TargetLevel is the CSP level of the browsers the policy is for. It changes which sources
+	// [ScriptSrc.Optimize] removes, and whether [ScriptSrc.LevelWarnings] warns about features
+	// those browsers don't support.
+	//
+	// The zero value for this is [CSPLevelAny].
+	TargetLevel CSPLevel
+
+	// HostNormalizer, if set, is applied to the host of each src, such as https://www.example.com,
+	// before it's added to Hosts, so conventions such as stripping "www." or mapping staging hosts
+	// to production can be enforced. Hosts it maps to the same value are only added once.
+	//
+	// If it returns an empty string, the host is dropped. It isn't applied to same-origin srcs, or
+	// to hosts added with [ScriptSrc.AddHost] or [ScriptSrc.AddSource].
+	HostNormalizer func(host string) string
+
+	// NoHosts forbids host sources, for pages that should only load scripts from the same origin,
+	// or by hash, without any third-party dependencies. Scripts, and links that fetch scripts, that
+	// require a host are errors wrapping [ErrHostNotAllowed], instead of being added to Hosts.
+	//
+	// Hosts added with [ScriptSrc.AddHost] or [ScriptSrc.AddSource] are still allowed, and so are
+	// external scripts that are hashed, rather than allowed by host, see ExternalScripts.
+	NoHosts bool
+
+	// Warnings are diagnostics found by [ScriptSrc.AddFromHTML] that don't affect the sources, but
+	// often indicate authoring mistakes, such as a script tag with both a src attribute and content.
+	Warnings []string
+
+	// InsecureHosts are the http origins, such as http://cdn.example.com, of insecure srcs found by
+	// [ScriptSrc.AddFromHTML]. They're never included in the policy, since a secure page can't load
+	// scripts over http, but are recorded so they can be reviewed and upgraded to https.
+	InsecureHosts []string
+
+	// PreScan enables a quick scan of files, before parsing them, in [ScriptSrc.AddFromHTMLFile].
+	// Files that clearly contain no script or link tags (or event handlers, if included) are skipped
+	// without being parsed, which can speed up processing large sites.
+	//
+	// The scan is conservative, it never skips a file with scripts, but files with text like " on"
+	// are still parsed when event handlers are included.
+	PreScan bool
+
+	// IncludeConnectionHints adds the hosts of <link rel="preconnect"> and <link rel="dns-prefetch">
+	// elements, which often hint at hosts that scripts are loaded from dynamically.
+	//
+	// Links that fetch scripts, such as <link rel="modulepreload"> and
+	// <link rel="preload" as="script">, are always included.
+	IncludeConnectionHints bool
+
+	// Visitor, if set, is called for every script found by [ScriptSrc.AddFromHTML].
+	Visitor Visitor
+
+	// MaxBytes, if positive, is the maximum size of the formatted directive, including the leading
+	// "script-src ", that [ScriptSrc.CheckSize] allows.
+	//
+	// Browsers, servers and CDNs limit the size of headers, so a policy with many hashes can fail to
+	// deploy.
+	MaxBytes int
+
+	// StrictSources makes [ScriptSrc.AddSource] and [ScriptSrc.Validate] reject Others that aren't
+	// recognized CSP sources, such as the typo 'unsafe_eval'. Otherwise, AddSource only adds a
+	// warning for them, since browsers may support sources this package doesn't know about.
+	StrictSources bool
+
+	// Others are strings, to be added as they appear (without quotes, but surrounding spaces will be added).
+	//
+	// Leading and trailing whitespace is trimmed when formatted, and entries that are empty after
+	// trimming, or duplicates, are skipped. Use [ScriptSrc.Validate] to check entries are valid
+	// sources, and [ScriptSrc.AddOther] to add entries without duplicates.
 	Others []string
+
+	// The indexes of Hashes, EventHandlerHashes, Hosts, Others, InsecureHosts and Files, so adding
+	// to them doesn't scan them for duplicates.
+	hashesIndex             uniqueIndex
+	eventHandlerHashesIndex uniqueIndex
+	hostsIndex              uniqueIndex
+	othersIndex             uniqueIndex
+	insecureHostsIndex      uniqueIndex
+	filesIndex              uniqueIndex
+
+	// sealed is set by [ScriptSrc.Seal].
+	sealed bool
+}
+
+// IsEmpty reports whether scriptSrc has no sources, so String returns 'none'. For example, this is
+// the case after adding an HTML file with no scripts.
+func (scriptSrc *ScriptSrc) IsEmpty() bool {
+	return len(scriptSrc.Sources()) == 0
+}
+
+// includesSelf reports whether 'self' is included, according to Self and SelfMode.
+func (scriptSrc *ScriptSrc) includesSelf() bool {
+	switch scriptSrc.SelfMode {
+	case SelfAlways:
+		return true
+	case SelfNever:
+		return false
+	default:
+		return scriptSrc.Self
+	}
 }
 
 // String formats this scriptSrc as it should appear in the Content-Security-Policy header value.
@@ -127,142 +397,1120 @@ type ScriptSrc struct {
 // In the header value, it should appear after "script-src", for example:
 //
 //	Content-Security-Policy: script-src 'self' https://challenges.cloudflare.com;
+//
+// If there are no sources, such as when no scripts were found, this is 'none', which blocks all
+// scripts, since an empty script-src isn't valid.
 func (scriptSrc *ScriptSrc) String() string {
-	srcs := make([]string, 0, 1+len(scriptSrc.Hashes)+len(scriptSrc.Hosts)+len(scriptSrc.Others))
-	if scriptSrc.Self {
+	return strings.Join(scriptSrc.directiveSources(), " ")
+}
+
+// directiveSources returns scriptSrc.Sources, or just 'none' if there are no sources.
+func (scriptSrc *ScriptSrc) directiveSources() []string {
+	srcs := scriptSrc.Sources()
+	if len(srcs) == 0 {
+		return []string{"'none'"}
+	}
+	return srcs
+}
+
+// Sources returns each source of this scriptSrc, formatted as it should appear in the
+// Content-Security-Policy header value, for example "'self'" or "https://challenges.cloudflare.com".
+func (scriptSrc *ScriptSrc) Sources() []string {
+	srcs := make([]string, 0, 3+len(scriptSrc.Hashes)+len(scriptSrc.Hosts)+len(scriptSrc.Others))
+	if scriptSrc.includesSelf() {
 		srcs = append(srcs, "'self'")
 	}
+	if scriptSrc.StrictDynamic {
+		srcs = append(srcs, "'strict-dynamic'")
+	}
+	if scriptSrc.ReportSample {
+		srcs = append(srcs, "'report-sample'")
+	}
 	for _, hash := range scriptSrc.Hashes {
 		srcs = append(srcs, "'"+hash+"'")
 	}
 	srcs = append(srcs, scriptSrc.Hosts...)
-	srcs = append(srcs, scriptSrc.Others...)
-	return strings.Join(srcs, " ")
+	others := len(srcs)
+	for _, other := range scriptSrc.Others {
+		if other = strings.TrimSpace(other); other != "" && !slices.Contains(srcs[others:], other) {
+			srcs = append(srcs, other)
+		}
+	}
+	return srcs
+}
+
+// appendUnique appends each value to slice, if it isn't already present.
+func appendUnique(slice []string, values ...string) []string {
+	for _, value := range values {
+		if !slices.Contains(slice, value) {
+			slice = append(slice, value)
+		}
+	}
+	return slice
+}
+
+// AddOther adds src to scriptSrc.Others, trimmed of surrounding whitespace, if it isn't already
+// present.
+func (scriptSrc *ScriptSrc) AddOther(src string) {
+	scriptSrc.panicIfSealed("AddOther")
+	if src = strings.TrimSpace(src); src != "" {
+		scriptSrc.Others = scriptSrc.othersIndex.appendUnique(scriptSrc.Others, src)
+	}
+}
+
+// warn adds a warning to scriptSrc.Warnings.
+func (scriptSrc *ScriptSrc) warn(format string, args ...any) {
+	scriptSrc.Warnings = append(scriptSrc.Warnings, fmt.Sprintf(format, args...))
+}
+
+// Merge adds all the sources from other into scriptSrc, skipping any that are already present.
+//
+// Only sources, and their Labels and Contents, Files, Warnings and InsecureHosts are merged.
+// Configuration such as DefaultHashAlgorithm is left unchanged.
+func (scriptSrc *ScriptSrc) Merge(other *ScriptSrc) {
+	scriptSrc.panicIfSealed("Merge")
+	scriptSrc.Self = scriptSrc.Self || other.Self
+	scriptSrc.StrictDynamic = scriptSrc.StrictDynamic || other.StrictDynamic
+	scriptSrc.ReportSample = scriptSrc.ReportSample || other.ReportSample
+	scriptSrc.Hashes = scriptSrc.hashesIndex.appendUnique(scriptSrc.Hashes, other.Hashes...)
+	scriptSrc.EventHandlerHashes = scriptSrc.eventHandlerHashesIndex.appendUnique(scriptSrc.EventHandlerHashes, other.EventHandlerHashes...)
+	scriptSrc.Hosts = scriptSrc.hostsIndex.appendUnique(scriptSrc.Hosts, other.Hosts...)
+	for _, src := range other.Others {
+		scriptSrc.AddOther(src)
+	}
+	scriptSrc.Warnings = append(scriptSrc.Warnings, other.Warnings...)
+	scriptSrc.InsecureHosts = scriptSrc.insecureHostsIndex.appendUnique(scriptSrc.InsecureHosts, other.InsecureHosts...)
+	scriptSrc.Files = scriptSrc.filesIndex.appendUnique(scriptSrc.Files, other.Files...)
+	for hash, content := range other.Contents {
+		if scriptSrc.Contents == nil {
+			scriptSrc.Contents = make(map[string]string)
+		}
+		scriptSrc.Contents[hash] = content
+	}
+	for hash, label := range other.Labels {
+		if _, ok := scriptSrc.Labels[hash]; ok {
+			continue
+		}
+		if scriptSrc.Labels == nil {
+			scriptSrc.Labels = make(map[string]string)
+		}
+		scriptSrc.Labels[hash] = label
+	}
+}
+
+// hashAlgorithmPrefixes are the CSP hash source prefixes for each HashAlgorithm.
+var hashAlgorithmPrefixes = [...]string{
+	Sha512: "sha512-",
+	Sha256: "sha256-",
+}
+
+// hasher is a reusable hash.Hash, along with scratch space for hashing and encoding without
+// allocating.
+type hasher struct {
+	hash.Hash
+	buf     [512]byte
+	sum     [sha512.Size]byte
+	encoded [88]byte // base64.StdEncoding.EncodedLen(sha512.Size)
+}
+
+// writeString writes s to the hash without copying s into a new byte slice.
+func (h *hasher) writeString(s string) {
+	for len(s) > 0 {
+		n := copy(h.buf[:], s)
+		h.Write(h.buf[:n])
+		s = s[n:]
+	}
+}
+
+// hasherPools holds reusable hashers for each HashAlgorithm, to avoid allocating a new hasher for
+// every inline script.
+var hasherPools = [...]sync.Pool{
+	Sha512: {New: func() any { return &hasher{Hash: sha512.New()} }},
+	Sha256: {New: func() any { return &hasher{Hash: sha256.New()} }},
 }
 
 // AddInline adds the hash of some inline JavaScript to this scriptSrc.Hashes
 //
 // The hash type is specified by scriptSrc.DefaultHashAlgorithm
 func (scriptSrc *ScriptSrc) AddInline(content string) {
-	var hash string
-	switch scriptSrc.DefaultHashAlgorithm {
-	case Sha512:
-		h := sha512.New()
-		h.Write([]byte(content))
-		hash = "sha512-" + base64.StdEncoding.EncodeToString(h.Sum(nil))
-	case Sha256:
-		h := sha256.New()
-		h.Write([]byte(content))
-		hash = "sha256-" + base64.StdEncoding.EncodeToString(h.Sum(nil))
-	default:
-		panic(fmt.Errorf("invalid HashAlgorithm value from DefaultHashAlgorithm: %v", scriptSrc.DefaultHashAlgorithm))
+	scriptSrc.panicIfSealed("AddInline")
+	scriptSrc.addInline(content)
+}
+
+// AddInlineWithLabel is like scriptSrc.AddInline, but also associates label with the hash in
+// scriptSrc.Labels.
+//
+// If the hash already has a label, it is replaced.
+func (scriptSrc *ScriptSrc) AddInlineWithLabel(content, label string) {
+	scriptSrc.panicIfSealed("AddInlineWithLabel")
+	hash := scriptSrc.addInline(content)
+	if scriptSrc.Labels == nil {
+		scriptSrc.Labels = make(map[string]string)
+	}
+	scriptSrc.Labels[hash] = label
+}
+
+// AddInlineBundle adds a single hash, of the contents concatenated in order with separator between
+// each, to scriptSrc.Hashes, for an inline script that is a bundle of them.
+//
+// The separator must exactly match what the bundle served uses, such as "\n" or ";\n", since any
+// difference changes the hash. No separator is added before the first, or after the last, content.
+func (scriptSrc *ScriptSrc) AddInlineBundle(contents []string, separator string) {
+	scriptSrc.panicIfSealed("AddInlineBundle")
+	scriptSrc.addInline(strings.Join(contents, separator))
+}
+
+// AddInlines adds the hash of each of the contents to scriptSrc.Hashes, exactly as calling
+// scriptSrc.AddInline for each would, but hashing them concurrently.
+func (scriptSrc *ScriptSrc) AddInlines(contents []string) {
+	scriptSrc.panicIfSealed("AddInlines")
+	if scriptSrc.NormalizeLineEndings {
+		normalized := make([]string, len(contents))
+		for i, content := range contents {
+			normalized[i] = normalizeLineEndings(content)
+		}
+		contents = normalized
+	}
+	hashes := make([]string, len(contents))
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range min(runtime.GOMAXPROCS(0), len(contents)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := int(next.Add(1) - 1); i < len(contents); i = int(next.Add(1) - 1) {
+				hash, err := hashInline(contents[i], scriptSrc.DefaultHashAlgorithm, scriptSrc.HashEncoding)
+				if err != nil {
+					// Invalid configuration is the only error, so every content would fail, and
+					// the panic is raised below, on the calling goroutine.
+					return
+				}
+				hashes[i] = hash
+			}
+		}()
+	}
+	wg.Wait()
+	for i, hash := range hashes {
+		if hash == "" {
+			scriptSrc.addInline(contents[i])
+		} else {
+			scriptSrc.addHash(hash, contents[i])
+		}
+	}
+}
+
+// addInline adds the hash of content to scriptSrc.Hashes, returning the hash.
+func (scriptSrc *ScriptSrc) addInline(content string) string {
+	if scriptSrc.NormalizeLineEndings {
+		content = normalizeLineEndings(content)
+	}
+	hash, err := hashInline(content, scriptSrc.DefaultHashAlgorithm, scriptSrc.HashEncoding)
+	if err != nil {
+		panic(err)
+	}
+	scriptSrc.addHash(hash, content)
+	return hash
+}
+
+// RemoveInline removes the hash of content, using scriptSrc.DefaultHashAlgorithm, from
+// scriptSrc.Hashes, along with its label and content, if kept. It reports whether the hash was
+// present.
+//
+// This is the inverse of scriptSrc.AddInline, so it's useful for removing the hash of a script
+// that is no longer used.
+func (scriptSrc *ScriptSrc) RemoveInline(content string) bool {
+	scriptSrc.panicIfSealed("RemoveInline")
+	if scriptSrc.NormalizeLineEndings {
+		content = normalizeLineEndings(content)
+	}
+	hash, err := hashInline(content, scriptSrc.DefaultHashAlgorithm, scriptSrc.HashEncoding)
+	if err != nil {
+		panic(err)
+	}
+	i := slices.Index(scriptSrc.Hashes, hash)
+	if i < 0 {
+		return false
+	}
+	scriptSrc.Hashes = slices.Delete(scriptSrc.Hashes, i, i+1)
+	if i := slices.Index(scriptSrc.EventHandlerHashes, hash); i >= 0 {
+		scriptSrc.EventHandlerHashes = slices.Delete(scriptSrc.EventHandlerHashes, i, i+1)
+	}
+	delete(scriptSrc.Contents, hash)
+	delete(scriptSrc.Labels, hash)
+	return true
+}
+
+// normalizeLineEndings replaces "\r\n" with "\n" in content.
+func normalizeLineEndings(content string) string {
+	return strings.ReplaceAll(content, "\r\n", "\n")
+}
+
+// addHash adds hash, the hash of content, to scriptSrc.Hashes.
+func (scriptSrc *ScriptSrc) addHash(hash, content string) {
+	scriptSrc.Hashes = scriptSrc.hashesIndex.appendUnique(scriptSrc.Hashes, hash)
+	if scriptSrc.KeepContents {
+		if scriptSrc.Contents == nil {
+			scriptSrc.Contents = make(map[string]string)
+		}
+		scriptSrc.Contents[hash] = content
+	}
+}
+
+// HashEntry is a parsed entry of ScriptSrc.Hashes.
+type HashEntry struct {
+	// Algorithm is the algorithm of the hash.
+	Algorithm HashAlgorithm
+
+	// Value is the base64 encoded digest, without the algorithm prefix.
+	Value string
+}
+
+// HashEntries returns each of the Hashes parsed into its algorithm and value, in the same order as
+// Hashes.
+//
+// Hashes with an algorithm that isn't a HashAlgorithm, such as sha384 hashes added with
+// [ScriptSrc.AddSource], are skipped.
+func (scriptSrc *ScriptSrc) HashEntries() []HashEntry {
+	entries := make([]HashEntry, 0, len(scriptSrc.Hashes))
+	for _, hash := range scriptSrc.Hashes {
+		for alg, prefix := range hashAlgorithmPrefixes {
+			if value, ok := strings.CutPrefix(hash, prefix); ok {
+				entries = append(entries, HashEntry{Algorithm: HashAlgorithm(alg), Value: value})
+				break
+			}
+		}
+	}
+	return entries
+}
+
+// InlineContents returns the content of each of the Hashes whose content is known, in the same
+// order as Hashes. Contents are only known if KeepContents was set when they were added.
+func (scriptSrc *ScriptSrc) InlineContents() []string {
+	contents := make([]string, 0, len(scriptSrc.Contents))
+	for _, hash := range scriptSrc.Hashes {
+		if content, ok := scriptSrc.Contents[hash]; ok {
+			contents = append(contents, content)
+		}
+	}
+	return contents
+}
+
+// NormalizeAlgorithm rehashes every inline script using alg, so that the policy doesn't mix hash
+// algorithms, and sets DefaultHashAlgorithm to alg.
+//
+// This requires the content of every hash, so KeepContents must have been set when they were
+// added. If the content of any hash isn't known, an error is returned and scriptSrc isn't changed.
+func (scriptSrc *ScriptSrc) NormalizeAlgorithm(alg HashAlgorithm) error {
+	if err := scriptSrc.checkSealed("NormalizeAlgorithm"); err != nil {
+		return err
+	}
+	if int(alg) >= len(hasherPools) {
+		return fmt.Errorf("invalid HashAlgorithm value: %v", alg)
+	}
+	for _, hash := range scriptSrc.Hashes {
+		if _, ok := scriptSrc.Contents[hash]; !ok {
+			return fmt.Errorf("content of hash %v isn't known, KeepContents must be set to normalize the algorithm", hash)
+		}
+	}
+	hashes := make([]string, 0, len(scriptSrc.Hashes))
+	contents := make(map[string]string, len(scriptSrc.Hashes))
+	var labels map[string]string
+	rehashed := make(map[string]string, len(scriptSrc.Hashes))
+	for _, oldHash := range scriptSrc.Hashes {
+		content := scriptSrc.Contents[oldHash]
+		hash, err := hashInline(content, alg, scriptSrc.HashEncoding)
+		if err != nil {
+			return err
+		}
+		hashes = appendUnique(hashes, hash)
+		contents[hash] = content
+		rehashed[oldHash] = hash
+		if label, ok := scriptSrc.Labels[oldHash]; ok {
+			if _, ok := labels[hash]; !ok {
+				if labels == nil {
+					labels = make(map[string]string)
+				}
+				labels[hash] = label
+			}
+		}
+	}
+	var eventHandlerHashes []string
+	for _, oldHash := range scriptSrc.EventHandlerHashes {
+		if hash, ok := rehashed[oldHash]; ok {
+			eventHandlerHashes = appendUnique(eventHandlerHashes, hash)
+		}
+	}
+	scriptSrc.Hashes = hashes
+	scriptSrc.EventHandlerHashes = eventHandlerHashes
+	scriptSrc.Contents = contents
+	scriptSrc.Labels = labels
+	scriptSrc.DefaultHashAlgorithm = alg
+	return nil
+}
+
+// HashInline returns the hash of content using alg, of the form <hash-algorithm>-<base64-hash>,
+// such as sha512-..., as it appears in Hashes, without any ScriptSrc. Surround it with single quotes
+// for use as a source in a policy.
+//
+// Unlike scriptSrc.AddInline, which panics, an error is returned if alg is invalid.
+func HashInline(content string, alg HashAlgorithm) (string, error) {
+	return hashInline(content, alg, StdBase64)
+}
+
+// hashInline returns the hash of content, of the form <hash-algorithm>-<base64-hash>.
+func hashInline(content string, alg HashAlgorithm, encoding HashEncoding) (string, error) {
+	if err := checkHashConfig(alg, encoding); err != nil {
+		return "", err
+	}
+	h := hasherPools[alg].Get().(*hasher)
+	h.Reset()
+	h.writeString(content)
+	sum := h.Sum(h.sum[:0])
+	encoded := h.encoded[:hashEncodings[encoding].EncodedLen(len(sum))]
+	hashEncodings[encoding].Encode(encoded, sum)
+	hash := hashAlgorithmPrefixes[alg] + string(encoded)
+	hasherPools[alg].Put(h)
+	return hash, nil
+}
+
+// checkHashConfig returns an error if alg or encoding isn't a supported value.
+func checkHashConfig(alg HashAlgorithm, encoding HashEncoding) error {
+	if int(alg) >= len(hasherPools) {
+		return fmt.Errorf("invalid HashAlgorithm value: %v", alg)
+	}
+	if int(encoding) >= len(hashEncodings) {
+		return fmt.Errorf("invalid HashEncoding value: %v", encoding)
+	}
+	return nil
+}
+
+// DebugHashes returns the hash source of content under every supported algorithm and encoding, to
+// help track down why a hash doesn't match the one a browser reports.
+//
+// The keys are the algorithm, such as "sha256", followed by "-url" for [URLBase64] encoded hashes,
+// and the values are the quoted hash sources, as they would appear in a policy.
+//
+// Browsers expect standard base64, so if the hash the browser expects only matches a "-url" entry,
+// the tool that generated the policy is using the wrong encoding.
+func DebugHashes(content string) map[string]string {
+	hashes := make(map[string]string, len(hasherPools)*len(hashEncodings))
+	for alg := range hasherPools {
+		for encoding := range hashEncodings {
+			name := strings.TrimSuffix(hashAlgorithmPrefixes[alg], "-")
+			if HashEncoding(encoding) == URLBase64 {
+				name += "-url"
+			}
+			hash, err := hashInline(content, HashAlgorithm(alg), HashEncoding(encoding))
+			if err != nil {
+				panic(err)
+			}
+			hashes[name] = "'" + hash + "'"
+		}
+	}
+	return hashes
+}
+
+// AddURL is like scriptSrc.AddSrc, but adds u, an already parsed URL, such as one computed from
+// an http.Request, without formatting and parsing it again. u is only formatted for error messages.
+//
+// This function returns an error if u is nil.
+func (scriptSrc *ScriptSrc) AddURL(u *url.URL) error {
+	if err := scriptSrc.checkSealed("AddURL"); err != nil {
+		return err
+	}
+	if u == nil {
+		return errors.New("AddURL called with a nil URL")
 	}
-	if !slices.Contains(scriptSrc.Hashes, hash) {
-		scriptSrc.Hashes = append(scriptSrc.Hashes, hash)
+	host, err := urlHostSource("script src", u, u)
+	if err != nil {
+		return err
 	}
+	return scriptSrc.addHostSource(host, u)
 }
 
 // AddSrc adds either 'self' or the required host entry to scriptSrc to allow the provided script source to be loaded.
 //
-// This function returns an error if the script src is http, not https.
+// Protocol-relative srcs, such as //cdn.example.com/lib.js, are treated as https. Any userinfo,
+// path, query or fragment is dropped, since host sources only contain the scheme, host and port.
+//
+// This function returns an error if the script src is http, not https, or if the host can't be
+// expressed as a CSP host source, such as an IPv6 address.
 func (scriptSrc *ScriptSrc) AddSrc(srcString string) error {
-	src, err := url.Parse(srcString)
+	if err := scriptSrc.checkSealed("AddSrc"); err != nil {
+		return err
+	}
+	host, err := hostSource("script", srcString)
 	if err != nil {
-		return fmt.Errorf("failed to parse script src %v: %w", srcString, err)
+		return err
+	}
+	return scriptSrc.addHostSource(host, rawSrc(srcString))
+}
+
+// addIntegrity adds the hashes in the integrity attribute of the script element n, if any, to
+// scriptSrc.Hashes.
+//
+// The integrity attribute is a whitespace separated list of hashes, such as sha384-..., each
+// optionally followed by options after a "?", which are ignored. Invalid hashes are warnings.
+func (scriptSrc *ScriptSrc) addIntegrity(n *html.Node) {
+	for _, attr := range n.Attr {
+		if strings.EqualFold(attr.Key, "integrity") {
+			scriptSrc.addIntegrityValue(attr.Val)
+		}
+	}
+}
+
+// addIntegrityValue adds the hashes in integrity, the value of an integrity attribute, to
+// scriptSrc.Hashes.
+func (scriptSrc *ScriptSrc) addIntegrityValue(integrity string) {
+	for _, hash := range strings.Fields(integrity) {
+		hash, _, _ = strings.Cut(hash, "?")
+		if _, _, err := parseHashSource(hash); err != nil {
+			scriptSrc.warn("ignoring invalid integrity hash: %v", err)
+			continue
+		}
+		scriptSrc.Hashes = scriptSrc.hashesIndex.appendUnique(scriptSrc.Hashes, hash)
+	}
+}
+
+// ErrHostNotAllowed is returned, wrapped, for srcs that require a host source when
+// ScriptSrc.NoHosts is set.
+var ErrHostNotAllowed = errors.New("host sources aren't allowed")
+
+// checkCrossOrigin warns if the script element n, with the given src, has an integrity attribute,
+// but is loaded from another host without a crossorigin attribute.
+//
+// Browsers can only check the integrity of cross-origin scripts fetched with CORS, so they block
+// such scripts. Module scripts are always fetched with CORS, so don't need the attribute.
+func (scriptSrc *ScriptSrc) checkCrossOrigin(n *html.Node, src string) {
+	hasIntegrity, hasCrossOrigin, isModule := false, false, false
+	for _, attr := range n.Attr {
+		switch strings.ToLower(attr.Key) {
+		case "integrity":
+			hasIntegrity = true
+		case "crossorigin":
+			hasCrossOrigin = true
+		case "type":
+			isModule = strings.EqualFold(strings.TrimSpace(attr.Val), "module")
+		}
+	}
+	if !hasIntegrity || hasCrossOrigin || isModule {
+		return
+	}
+	if host, err := hostSource("script", src); err == nil && host != "" {
+		scriptSrc.warn("script %v has an integrity attribute, but no crossorigin attribute, so browsers will block it", src)
+	}
+}
+
+// addHostSource adds host, as returned by hostSource for src, setting Self if it's empty, and
+// otherwise applying scriptSrc.HostNormalizer.
+//
+// If scriptSrc.NoHosts is set, an error wrapping ErrHostNotAllowed is returned instead of adding
+// the host.
+func (scriptSrc *ScriptSrc) addHostSource(host string, src fmt.Stringer) error {
+	if host == "" {
+		scriptSrc.Self = true
+		return nil
+	}
+	if scriptSrc.HostNormalizer != nil {
+		host = scriptSrc.HostNormalizer(host)
+		if host == "" {
+			return nil
+		}
+	}
+	if scriptSrc.NoHosts {
+		return fmt.Errorf("%w: %v requires %v", ErrHostNotAllowed, strings.TrimSpace(src.String()), host)
+	}
+	scriptSrc.Hosts = scriptSrc.hostsIndex.appendUnique(scriptSrc.Hosts, host)
+	return nil
+}
+
+// addFromLink adds the host of a link element that fetches a script, such as
+// <link rel="modulepreload" href="...">, or <link rel="preload" as="script" href="...">, which
+// must be allowed by script-src.
+//
+// If scriptSrc.IncludeConnectionHints is set, the hosts of preconnect and dns-prefetch links are
+// also added, but errors parsing them are warnings, since the browser never runs scripts from them.
+func (scriptSrc *ScriptSrc) addFromLink(n *html.Node) error {
+	var rels []string
+	as, href, hasHref := "", "", false
+	for _, attr := range n.Attr {
+		switch strings.ToLower(attr.Key) {
+		case "rel":
+			rels = strings.Fields(strings.ToLower(attr.Val))
+		case "as":
+			as = strings.ToLower(strings.TrimSpace(attr.Val))
+		case "href":
+			href, hasHref = attr.Val, true
+		}
+	}
+	if !hasHref {
+		return nil
+	}
+	if slices.Contains(rels, "modulepreload") ||
+		(as == "script" && (slices.Contains(rels, "preload") || slices.Contains(rels, "prefetch"))) {
+		// Unlike script srcs, invalid and insecure link hrefs are always errors, regardless of
+		// ErrorOnInsecure.
+		host, err := hostSource("link", href)
+		if err != nil {
+			return err
+		}
+		return scriptSrc.addHostSource(host, rawSrc(href))
 	}
+	if scriptSrc.IncludeConnectionHints && (slices.Contains(rels, "preconnect") || slices.Contains(rels, "dns-prefetch")) {
+		host, err := hostSource("link", href)
+		if err != nil {
+			scriptSrc.warn("ignoring connection hint: %v", err)
+		} else if host != "" {
+			err := scriptSrc.addHostSource(host, rawSrc(href))
+			if err != nil {
+				scriptSrc.warn("ignoring connection hint: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// AddHost adds host, which must be a host source such as https://cdn.example.com, to
+// scriptSrc.Hosts if it isn't already present.
+//
+// Unlike AddSrc, host isn't parsed as a URL, so it's added exactly as given, as long as it is a valid
+// CSP host source. This includes wildcards, such as https://*.example.com, and any scheme.
+func (scriptSrc *ScriptSrc) AddHost(host string) error {
+	if err := scriptSrc.checkSealed("AddHost"); err != nil {
+		return err
+	}
+	if err := validateHostSource(host); err != nil {
+		return err
+	}
+	scriptSrc.Hosts = scriptSrc.hostsIndex.appendUnique(scriptSrc.Hosts, host)
+	return nil
+}
+
+// HostURLs returns each of the Hosts parsed as a URL, in the same order as Hosts.
+//
+// Hosts without a scheme, such as cdn.example.com, are parsed as protocol-relative, so have an
+// empty Scheme. Wildcard hosts, such as https://*.example.com, have a Host starting with "*.", but
+// hosts with a wildcard port, which url.Parse doesn't allow, return an error.
+func (scriptSrc *ScriptSrc) HostURLs() ([]*url.URL, error) {
+	urls := make([]*url.URL, 0, len(scriptSrc.Hosts))
+	for _, host := range scriptSrc.Hosts {
+		raw := host
+		if !strings.Contains(host, "://") {
+			raw = "//" + host
+		}
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse host %v: %w", host, err)
+		}
+		urls = append(urls, u)
+	}
+	return urls, nil
+}
+
+// AddSource adds src, a source exactly as it would appear in the policy, such as 'self',
+// 'unsafe-eval', 'sha256-...', https: or https://cdn.example.com, to the appropriate field of
+// scriptSrc, if it isn't already present.
+//
+// This is useful for sources that are always required, but can't be found in the HTML, such as
+// hosts of scripts that are loaded dynamically. An error is returned if src isn't a valid source.
+//
+// Quoted sources that aren't recognized keywords, nonces or hashes, which are usually typos, are
+// still added, with a warning, unless scriptSrc.StrictSources is set, in which case they are
+// errors.
+func (scriptSrc *ScriptSrc) AddSource(src string) error {
+	if err := scriptSrc.checkSealed("AddSource"); err != nil {
+		return err
+	}
+	src = strings.TrimSpace(src)
+	switch {
+	case src == "'self'":
+		scriptSrc.Self = true
+	case src == "'strict-dynamic'":
+		scriptSrc.StrictDynamic = true
+	case src == "'report-sample'":
+		scriptSrc.ReportSample = true
+	case strings.HasPrefix(src, "'sha256-") || strings.HasPrefix(src, "'sha384-") || strings.HasPrefix(src, "'sha512-"):
+		if !strings.HasSuffix(src, "'") {
+			return fmt.Errorf("invalid hash source %v", src)
+		}
+		if _, _, err := parseHashSource(src[1 : len(src)-1]); err != nil {
+			return fmt.Errorf("invalid hash source: %w", err)
+		}
+		scriptSrc.Hashes = scriptSrc.hashesIndex.appendUnique(scriptSrc.Hashes, src[1:len(src)-1])
+	case strings.HasPrefix(src, "'"), schemeSourcePattern.MatchString(src):
+		if err := validateSource(src); err != nil {
+			return err
+		}
+		if !isRecognizedSource(src) {
+			if scriptSrc.StrictSources {
+				return fmt.Errorf("unrecognized source %v", src)
+			}
+			scriptSrc.warn("unrecognized source %v, check it isn't a typo", src)
+		}
+		scriptSrc.AddOther(src)
+	default:
+		return scriptSrc.AddHost(src)
+	}
+	return nil
+}
+
+// ErrInsecure is returned, wrapped, for http srcs, which can't be allowed by a secure policy.
+var ErrInsecure = errors.New("insecure")
+
+// addFoundSrc adds the host source required to load src, of the given kind, such as "script",
+// found in the HTML.
+//
+// Invalid srcs, such as http srcs, are warnings, since the browser won't load them with the
+// generated policy anyway, unless scriptSrc.ErrorOnInsecure is set and src is insecure.
+func (scriptSrc *ScriptSrc) addFoundSrc(kind, src string) error {
+	host, ok, err := scriptSrc.foundHostSource(kind, src)
+	if !ok {
+		return err
+	}
+	return scriptSrc.addHostSource(host, rawSrc(src))
+}
+
+// foundHostSource returns the host source required to load src, as addFoundSrc adds, and true, or
+// false if src was ignored.
+//
+// Insecure srcs are recorded in scriptSrc.InsecureHosts, and returned as errors if
+// scriptSrc.ErrorOnInsecure is set, other invalid srcs are ignored with a warning.
+func (scriptSrc *ScriptSrc) foundHostSource(kind, src string) (string, bool, error) {
+	host, err := hostSource(kind, src)
+	if err != nil {
+		if errors.Is(err, ErrInsecure) {
+			if scriptSrc.ErrorOnInsecure {
+				return "", false, err
+			}
+			// hostSource has already parsed src successfully.
+			u, _ := url.Parse(strings.TrimSpace(src))
+			scriptSrc.InsecureHosts = scriptSrc.insecureHostsIndex.appendUnique(scriptSrc.InsecureHosts, "http://"+strings.ToLower(u.Host))
+		}
+		scriptSrc.warn("ignoring %v", err)
+		return "", false, nil
+	}
+	return host, true, nil
+}
+
+// rawSrc is a src as written, such as in an attribute, which is passed to addHostSource and
+// urlHostSource for error messages.
+type rawSrc string
+
+func (src rawSrc) String() string {
+	return string(src)
+}
+
+// hostSource returns the host source required to load srcString, or "" if it is relative, so
+// requires 'self'. kind is the kind of src, such as "script", used in error messages.
+func hostSource(kind, srcString string) (string, error) {
+	name := "src"
+	if kind != "" {
+		name = kind + " src"
+	}
+	// Browsers strip leading and trailing whitespace from URL attributes.
+	src, err := url.Parse(strings.TrimSpace(srcString))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %v %q: %w", name, srcString, err)
+	}
+	return urlHostSource(name, src, rawSrc(srcString))
+}
+
+// urlHostSource is like hostSource, but for src, an already parsed URL. name is the name of the
+// src, such as "script src", and srcString is the src as written, both for error messages, so
+// srcString is only formatted if an error is returned.
+func urlHostSource(name string, src *url.URL, srcString fmt.Stringer) (string, error) {
 	switch src.Scheme {
 	case "http":
-		return fmt.Errorf("insecure script src: %v", srcString)
+		return "", fmt.Errorf("%w %v: %v", ErrInsecure, name, srcString)
 	case "https":
-		host := "https://" + src.Host
-		if !slices.Contains(scriptSrc.Hosts, host) {
-			scriptSrc.Hosts = append(scriptSrc.Hosts, host)
+	case "":
+		if src.Host == "" {
+			return "", nil
 		}
-		return nil
+		// Otherwise, this is protocol-relative, such as //cdn.example.com/lib.js, which is loaded
+		// using the protocol of the document, which should be https.
+	default:
+		return "", fmt.Errorf("failed to understand %v %v", name, srcString)
+	}
+	// Only the host (and port) is needed, any userinfo, path, query or fragment is dropped.
+	if src.Host == "" {
+		return "", fmt.Errorf("%v %q has no host", name, srcString)
+	}
+	if strings.HasPrefix(src.Host, "[") {
+		// The CSP host-source grammar only allows letters, digits, dashes and dots in hosts, so
+		// there is no way to allow an IPv6 literal, browsers ignore such sources.
+		return "", fmt.Errorf("%v %q has an IPv6 host, which can't be allowed by a CSP host source", name, srcString)
+	}
+	host := "https://" + src.Host
+	if err := validateSource(host); err != nil {
+		return "", fmt.Errorf("%v %q has an invalid host: %w", name, srcString, err)
+	}
+	return host, nil
+}
+
+// normalizeOrigin returns the https origin of u, without the default port, in lower case.
+func normalizeOrigin(u *url.URL) string {
+	host := strings.ToLower(u.Host)
+	host = strings.TrimSuffix(host, ":443")
+	return "https://" + host
+}
+
+// CollapseSelfOrigin removes any entries from Hosts that are the same as the document's own origin,
+// such as https://example.com, since they are equivalent to 'self'.
+//
+// If any hosts are removed, Self is set, so the resulting policy still allows the same scripts.
+//
+// An error is returned if origin isn't an https origin.
+func (scriptSrc *ScriptSrc) CollapseSelfOrigin(origin string) error {
+	if err := scriptSrc.checkSealed("CollapseSelfOrigin"); err != nil {
+		return err
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return fmt.Errorf("failed to parse origin %v: %w", origin, err)
+	}
+	if u.Scheme != "https" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+		return fmt.Errorf("invalid origin %v: must be of the form https://example.com", origin)
+	}
+	self := normalizeOrigin(u)
+	hosts := scriptSrc.Hosts[:0]
+	for _, host := range scriptSrc.Hosts {
+		hostURL, err := url.Parse(host)
+		if err == nil && hostURL.Scheme == "https" && normalizeOrigin(hostURL) == self {
+			scriptSrc.Self = true
+			continue
+		}
+		hosts = append(hosts, host)
+	}
+	scriptSrc.Hosts = hosts
+	return nil
+}
+
+// isScriptSrcAttr reports whether attr is the attribute of the script element n that specifies the
+// external script to load.
+//
+// For HTML scripts, this is src. For SVG scripts, this is href, or xlink:href if there is no href.
+func isScriptSrcAttr(n *html.Node, attr html.Attribute) bool {
+	if n.Namespace != "svg" {
+		return attr.Namespace == "" && strings.EqualFold(attr.Key, "src")
+	}
+	if !strings.EqualFold(attr.Key, "href") {
+		return false
+	}
+	switch attr.Namespace {
 	case "":
-		scriptSrc.Self = true
-		return nil
+		return true
+	case "xlink":
+		return !slices.ContainsFunc(n.Attr, func(other html.Attribute) bool {
+			return other.Namespace == "" && strings.EqualFold(other.Key, "href")
+		})
 	default:
-		return fmt.Errorf("failed to understand script src %v", srcString)
+		return false
 	}
 }
 
 // AddFromHTML adds the required script sources for loading all scripts, recursively, within the node.
 //
 // This adds entries from script src attributes, and content within script tags without src attributes.
+// This includes SVG script elements, inside inline svg elements, whose src is given by href.
+// See scriptSrc.ExternalScripts for how script src attributes are handled.
 //
-// If includeEventHandlers, the content within any attribute starting with "on" is also allowed.
+// If includeEventHandlers, the content within event handler attributes, such as onclick, is also
+// allowed. See scriptSrc.IsEventHandler for which attributes are event handlers.
+//
+// Scripts inside HTML comments, such as <!-- <script>...</script> -->, are never added, since
+// browsers never run them. If a framework later uncomments them, they must be allowed separately.
+//
+// An error is returned, before anything is added, if DefaultHashAlgorithm or HashEncoding is
+// invalid, rather than panicking as AddInline does.
 func (scriptSrc *ScriptSrc) AddFromHTML(n *html.Node, includeEventHandlers bool) error {
-	// If the node is a script, add the src or content.
-	if n.Type == html.ElementNode && n.Data == "script" {
-		hasSrc := false
+	if err := scriptSrc.checkSealed("AddFromHTML"); err != nil {
+		return err
+	}
+	if err := checkHashConfig(scriptSrc.DefaultHashAlgorithm, scriptSrc.HashEncoding); err != nil {
+		return err
+	}
+	return scriptSrc.addFromHTML(n, includeEventHandlers, nil)
+}
+
+// addFromHTML implements AddFromHTML. If visitElement is set, it is also called for every element
+// node, other than scripts and their children, so other directives can be collected in the same walk.
+func (scriptSrc *ScriptSrc) addFromHTML(n *html.Node, includeEventHandlers bool, visitElement func(n *html.Node) error) error {
+	// If the node is an element requiring sources, such as a script, add them. Scripts are
+	// handled completely, including their content.
+	if handler := lookupElementHandler(scriptSrcElements, n); handler != nil {
+		done, err := handler.handle(scriptSrc, n)
+		if err != nil || done {
+			return err
+		}
+	}
+
+	if visitElement != nil && n.Type == html.ElementNode {
+		err := visitElement(n)
+		if err != nil {
+			return err
+		}
+	}
+
+	if includeEventHandlers {
 		for _, attr := range n.Attr {
-			if attr.Key == "src" {
-				if hasSrc {
-					return fmt.Errorf("script tag had a second src attribute: %v", attr.Val)
+			if scriptSrc.isEventHandler(attr.Key) {
+				if scriptSrc.Visitor != nil {
+					err := scriptSrc.Visitor.VisitEventHandler(n, attr)
+					if err != nil {
+						return err
+					}
 				}
-				scriptSrc.AddSrc(attr.Val)
-				hasSrc = true
-				// Don't return here, instead check there are no more src attributes.
+				hash := scriptSrc.addInline(attr.Val)
+				scriptSrc.EventHandlerHashes = scriptSrc.eventHandlerHashesIndex.appendUnique(scriptSrc.EventHandlerHashes, hash)
 			}
 		}
-		// If we found a src attribute, we're finished!
-		if hasSrc {
-			return nil
+	}
+
+	// Otherwise, process all the children.
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		err := scriptSrc.addFromHTML(c, includeEventHandlers, visitElement)
+		if err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		// Otherwise, this should be an inline script, so we should have exactly one child, which is
-		// a text node.
-		content := n.FirstChild
-		if content == nil {
-			return fmt.Errorf("script tag had no src attribute and no content")
+// addFromScript adds the src or content of the script element n.
+func (scriptSrc *ScriptSrc) addFromScript(n *html.Node) error {
+	hasSrc := false
+	src := ""
+	for _, attr := range srcAttrs(n) {
+		if hasSrc {
+			return fmt.Errorf("script tag had a second src attribute: %v", attr.Val)
 		}
-		if content.Type != html.TextNode {
-			return fmt.Errorf("script tag had a child that was not a text node")
+		if scriptSrc.Visitor != nil {
+			err := scriptSrc.Visitor.VisitExternalScript(n, attr.Val)
+			if err != nil {
+				return err
+			}
 		}
-		if content.NextSibling != nil || content.FirstChild != nil {
-			return fmt.Errorf("script tag had multiple children")
+		err := scriptSrc.addExternal(attr.Val)
+		if err != nil {
+			return err
 		}
-		scriptSrc.AddInline(content.Data)
-		return nil
+		hasSrc = true
+		src = attr.Val
+		// Don't return here, instead check there are no more src attributes.
 	}
-
-	if includeEventHandlers {
-		for _, attr := range n.Attr {
-			if strings.HasPrefix(attr.Key, "on") {
-				scriptSrc.AddInline(attr.Val)
+	// If we found a src attribute, we're finished!
+	if hasSrc {
+		if scriptSrc.IntegrityHashes && scriptSrc.StrictDynamic {
+			scriptSrc.addIntegrity(n)
+		}
+		scriptSrc.checkCrossOrigin(n, src)
+		// Browsers ignore the content of scripts with a src, so content is probably a mistake.
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.TextNode || strings.TrimSpace(c.Data) != "" {
+				scriptSrc.warn("script tag with src %v also has content, which is never executed", src)
+				break
 			}
 		}
+		return nil
 	}
 
-	// Otherwise, process all the children.
+	// Otherwise, this should be an inline script.
+	content, ok := scriptText(n)
+	if !ok {
+		return fmt.Errorf("script tag had no src attribute and no content")
+	}
 	for c := n.FirstChild; c != nil; c = c.NextSibling {
-		err := scriptSrc.AddFromHTML(c, includeEventHandlers)
+		if c.Type == html.ElementNode {
+			scriptSrc.warn("script tag has a %v element inside it, whose content is never executed", c.Data)
+			break
+		}
+	}
+	if scriptSrc.Visitor != nil {
+		err := scriptSrc.Visitor.VisitInlineScript(n, content)
 		if err != nil {
 			return err
 		}
 	}
+	scriptSrc.AddInline(content)
 	return nil
 }
 
+// scriptText returns the content of the inline script element n, as browsers run it, reporting
+// whether it has any text at all.
+//
+// For HTML scripts, the parser always produces a single text node, but SVG scripts are parsed as
+// normal content, so may have several, such as when a comment splits the script. Browsers run the
+// concatenation of the text node children, ignoring comments and the content of any elements, so
+// this does too.
+func scriptText(n *html.Node) (string, bool) {
+	var content strings.Builder
+	ok := false
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode {
+			content.WriteString(c.Data)
+			ok = true
+		}
+	}
+	return content.String(), ok
+}
+
+// AddFromHTMLReader parses r as HTML, and then calls scriptSrc.AddFromHTML with the result.
+func (scriptSrc *ScriptSrc) AddFromHTMLReader(r io.Reader, includeEventHandlers bool) error {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return fmt.Errorf("failed to parse HTML: %w", err)
+	}
+	return scriptSrc.AddFromHTML(doc, includeEventHandlers)
+}
+
+// AddFromHTMLString parses htmlString as HTML, and then calls scriptSrc.AddFromHTML with the result.
+func (scriptSrc *ScriptSrc) AddFromHTMLString(htmlString string, includeEventHandlers bool) error {
+	return scriptSrc.AddFromHTMLReader(strings.NewReader(htmlString), includeEventHandlers)
+}
+
+// ScriptSrcFromHTMLString generates the script-src required by the HTML document htmlString.
+//
+// The input must be truested HTML! See the package documentation if you're unsure.
+func ScriptSrcFromHTMLString(htmlString string, includeEventHandlers bool) (*ScriptSrc, error) {
+	scriptSrc := &ScriptSrc{}
+	err := scriptSrc.AddFromHTMLString(htmlString, includeEventHandlers)
+	if err != nil {
+		return nil, err
+	}
+	return scriptSrc, nil
+}
+
+// gzipMagic is the header that all gzip streams start with.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// readHTMLFile reads the file from path.
+//
+// If the file is gzip compressed (it starts with the gzip magic bytes), it is transparently
+// decompressed.
+func readHTMLFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readHTML(f, path)
+}
+
+// readHTML reads all of src, read from path, transparently decompressing it if it's gzip
+// compressed.
+func readHTML(src io.Reader, path string) ([]byte, error) {
+	r := bufio.NewReader(src)
+	magic, err := r.Peek(len(gzipMagic))
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read %v: %w", path, err)
+	}
+	var content io.Reader = r
+	if bytes.Equal(magic, gzipMagic) {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress %v: %w", path, err)
+		}
+		defer gz.Close()
+		content = gz
+	}
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %v: %w", path, err)
+	}
+	return data, nil
+}
+
+// parseHTMLFile parses the file from path as HTML, transparently decompressing it if it's gzip
+// compressed.
+func parseHTMLFile(path string) (*html.Node, error) {
+	data, err := readHTMLFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseHTMLData(path, data)
+}
+
+// parseHTMLData parses data, read from path, as HTML.
+func parseHTMLData(path string, data []byte) (*html.Node, error) {
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %v as HTML: %w", path, err)
+	}
+	return doc, nil
+}
+
 // AddFromHTMLFile parses the file from path, as HTML, and then calls scriptSrc.AddFromHTML with the result.
+//
+// If the file is gzip compressed (it starts with the gzip magic bytes), it is transparently
+// decompressed first, so precompressed .html.gz files can be processed directly.
+//
+// If scriptSrc.PreScan is set, files that clearly contain no scripts are skipped without being
+// parsed.
 func (scriptSrc *ScriptSrc) AddFromHTMLFile(path string, includeEventHandlers bool) error {
-	f, err := os.Open(path)
+	if err := scriptSrc.checkSealed("AddFromHTMLFile"); err != nil {
+		return err
+	}
+	data, err := readHTMLFile(path)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-	doc, err := html.Parse(f)
+	return scriptSrc.addFromHTMLData(path, data, includeEventHandlers)
+}
+
+// addFromHTMLData parses data, read from path, as HTML, and then calls scriptSrc.AddFromHTML with
+// the result, unless scriptSrc.PreScan is set and it clearly contains no scripts.
+func (scriptSrc *ScriptSrc) addFromHTMLData(path string, data []byte, includeEventHandlers bool) error {
+	if scriptSrc.PreScan && !scriptSrc.mayContainScripts(data, includeEventHandlers) {
+		return nil
+	}
+	doc, err := parseHTMLData(path, data)
 	if err != nil {
-		return fmt.Errorf("failed to parse %v as HTML: %w", path, err)
+		return err
 	}
 	err = scriptSrc.AddFromHTML(doc, includeEventHandlers)
 	if err != nil {
-		return fmt.Errorf("failed to process %v: %w", path, err)
+		return fmt.Errorf("failed to process %v: %w", path, withPosition(err, doc, data))
 	}
 	return nil
 }
 
+// mayContainScripts reports whether data might contain script or link tags, or event handlers if
+// includeEventHandlers. If this returns false, data definitely contains none of them.
+func (scriptSrc *ScriptSrc) mayContainScripts(data []byte, includeEventHandlers bool) bool {
+	for i := bytes.IndexByte(data, '<'); i >= 0; {
+		if len(data) >= i+7 && bytes.EqualFold(data[i+1:i+7], []byte("script")) {
+			return true
+		}
+		if len(data) >= i+5 && bytes.EqualFold(data[i+1:i+5], []byte("link")) {
+			return true
+		}
+		next := bytes.IndexByte(data[i+1:], '<')
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	if !includeEventHandlers {
+		return false
+	}
+	if scriptSrc.IsEventHandler != nil {
+		// A custom predicate could match any attribute.
+		return true
+	}
+	// Event handlers all start with "on", which must follow whitespace, a quote or a slash to be the
+	// start of an attribute name.
+	for i := 1; i+1 < len(data); i++ {
+		if (data[i] == 'o' || data[i] == 'O') && (data[i+1] == 'n' || data[i+1] == 'N') {
+			switch data[i-1] {
+			case ' ', '\t', '\n', '\r', '\f', '"', '\'', '/':
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // ScriptSrcFromHTMLFile generates the script-src required to load a requested HTML file.
 //
 // The input files must be truested HTML files! See the package documentation if you're unsure.
@@ -271,6 +1519,22 @@ func ScriptSrcFromHTMLFile(path string, includeEventHandlers bool) (*ScriptSrc,
 	return scriptSrc, scriptSrc.AddFromHTMLFile(path, includeEventHandlers)
 }
 
+// PreviewFromHTMLFile returns the sources that the HTML file at path alone contributes, in a new
+// ScriptSrc configured by opts.
+//
+// This is guaranteed never to include sources from any other file, so it can be used to inspect
+// what each file adds before merging them into a combined policy.
+//
+// The input file must be a truested HTML file! See the package documentation if you're unsure.
+func PreviewFromHTMLFile(path string, includeEventHandlers bool, opts ...Option) (*ScriptSrc, error) {
+	scriptSrc := New(opts...)
+	err := scriptSrc.AddFromHTMLFile(path, includeEventHandlers)
+	if err != nil {
+		return nil, err
+	}
+	return scriptSrc, nil
+}
+
 // ScriptSrcFromHTMLFiles generates the script-src required to load any of the requested HTML files.
 //
 // The input files must be truested HTML files! See the package documentation if you're unsure.
@@ -292,6 +1556,31 @@ func ScriptSrcFromHTMLFiles(paths []string, includeEventHandlers bool) (*ScriptS
 	}
 }
 
+// ScriptSrcsFromHTMLFiles generates a separate script-src for each of the HTML files, keyed by its
+// path, each configured by opts, for serving a separate policy for each page.
+//
+// Unlike ScriptSrcFromHTMLFiles, no file's policy allows the scripts of any other file.
+//
+// The input files must be truested HTML files! See the package documentation if you're unsure.
+func ScriptSrcsFromHTMLFiles(paths []string, includeEventHandlers bool, opts ...Option) (map[string]*ScriptSrc, error) {
+	scriptSrcs := make(map[string]*ScriptSrc, len(paths))
+	var errs []error
+	for _, path := range paths {
+		scriptSrc, err := PreviewFromHTMLFile(path, includeEventHandlers, opts...)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		scriptSrcs[path] = scriptSrc
+	}
+	if len(errs) == 1 {
+		return nil, errs[0]
+	} else if len(errs) > 1 {
+		return nil, fmt.Errorf("multiple errors: %v", errs)
+	}
+	return scriptSrcs, nil
+}
+
 // ScriptSrcFromHTMLFiles generates the script-src required to load any of the HTML files matching the glob pattern.
 //
 // The input files must be truested HTML files! See the package documentation if you're unsure.
@@ -302,3 +1591,84 @@ func ScriptSrcFromHTMLFileGlob(pattern string, includeEventHandlers bool) (*Scri
 	}
 	return ScriptSrcFromHTMLFiles(files, includeEventHandlers)
 }
+
+// isHTMLPath reports whether path has an extension of an HTML file that should be processed when
+// walking a directory, optionally followed by .gz.
+func isHTMLPath(path string) bool {
+	path = strings.ToLower(path)
+	switch filepath.Ext(strings.TrimSuffix(path, ".gz")) {
+	case ".html", ".htm":
+		return true
+	default:
+		return false
+	}
+}
+
+// FindHTMLFiles recursively walks the directory root, returning the path of every file with a
+// .html, .htm, .html.gz or .htm.gz extension, in lexical order. These are the files added by
+// [ScriptSrc.AddFromHTMLDir].
+func FindHTMLFiles(root string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && isHTMLPath(path) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// AddFromHTMLDir recursively walks the directory root, calling scriptSrc.AddFromHTMLFile for every
+// file with a .html, .htm, .html.gz or .htm.gz extension.
+func (scriptSrc *ScriptSrc) AddFromHTMLDir(root string, includeEventHandlers bool) error {
+	return scriptSrc.AddFromHTMLDirContext(context.Background(), root, includeEventHandlers)
+}
+
+// AddFromHTMLDirContext is like scriptSrc.AddFromHTMLDir, but checks ctx between files, aborting the
+// walk if it is done.
+//
+// If the walk was aborted, the returned error wraps ctx.Err(), so it can be detected with
+// errors.Is(err, context.Canceled). Sources from files processed before the cancellation remain in
+// scriptSrc.
+func (scriptSrc *ScriptSrc) AddFromHTMLDirContext(ctx context.Context, root string, includeEventHandlers bool) error {
+	if err := scriptSrc.checkSealed("AddFromHTMLDirContext"); err != nil {
+		return err
+	}
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return fmt.Errorf("walk of %v aborted: %w", root, ctxErr)
+		}
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !isHTMLPath(path) {
+			return nil
+		}
+		return scriptSrc.AddFromHTMLFile(path, includeEventHandlers)
+	})
+}
+
+// ScriptSrcFromHTMLDir generates the script-src required to load any of the HTML files within the
+// directory root, recursively.
+//
+// The input files must be truested HTML files! See the package documentation if you're unsure.
+func ScriptSrcFromHTMLDir(root string, includeEventHandlers bool) (*ScriptSrc, error) {
+	return ScriptSrcFromHTMLDirContext(context.Background(), root, includeEventHandlers)
+}
+
+// ScriptSrcFromHTMLDirContext is like ScriptSrcFromHTMLDir, but aborts when ctx is done. See
+// [ScriptSrc.AddFromHTMLDirContext].
+func ScriptSrcFromHTMLDirContext(ctx context.Context, root string, includeEventHandlers bool) (*ScriptSrc, error) {
+	scriptSrc := &ScriptSrc{}
+	err := scriptSrc.AddFromHTMLDirContext(ctx, root, includeEventHandlers)
+	if err != nil {
+		return nil, err
+	}
+	return scriptSrc, nil
+}
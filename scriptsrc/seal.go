@@ -0,0 +1,47 @@
+package scriptsrc
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrSealed is returned, wrapped, by methods that would modify a ScriptSrc after
+// [ScriptSrc.Seal] was called.
+var ErrSealed = errors.New("script-src is sealed")
+
+// Seal marks scriptSrc as read-only, so that methods that would modify it fail instead: those that
+// return an error return one wrapping [ErrSealed], such as [ScriptSrc.AddSrc] and
+// [ScriptSrc.AddFromHTML], and the others panic, such as [ScriptSrc.AddInline] and
+// [ScriptSrc.Merge]. Methods that only read it, such as String and Render, are unaffected.
+//
+// The intended lifecycle is to configure a ScriptSrc, add sources to it, post-process it (for
+// example, with [ScriptSrc.Optimize]), and then Seal it before sharing it, such as with templates
+// or other goroutines, so bugs that would modify it mid-render fail loudly.
+//
+// Sealing can't be undone. It doesn't protect the exported fields, which can still be modified
+// directly, and copies of a sealed ScriptSrc are sealed too. To modify a sealed policy, Merge it
+// into a new ScriptSrc.
+func (scriptSrc *ScriptSrc) Seal() {
+	scriptSrc.sealed = true
+}
+
+// Sealed reports whether [ScriptSrc.Seal] was called.
+func (scriptSrc *ScriptSrc) Sealed() bool {
+	return scriptSrc.sealed
+}
+
+// checkSealed returns an error wrapping ErrSealed if scriptSrc is sealed, naming method, the method
+// that would have modified it.
+func (scriptSrc *ScriptSrc) checkSealed(method string) error {
+	if scriptSrc.sealed {
+		return fmt.Errorf("%w: can't call %v", ErrSealed, method)
+	}
+	return nil
+}
+
+// panicIfSealed is like checkSealed, but panics, for methods that don't return an error.
+func (scriptSrc *ScriptSrc) panicIfSealed(method string) {
+	if err := scriptSrc.checkSealed(method); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,63 @@
+package scriptsrc
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSeal(t *testing.T) {
+	scriptSrc := ScriptSrc{DefaultHashAlgorithm: Sha256}
+	scriptSrc.AddInline("a")
+	if err := scriptSrc.AddSrc("https://cdn.example.com/lib.js"); err != nil {
+		t.Fatal(err)
+	}
+	scriptSrc.Seal()
+	if !scriptSrc.Sealed() {
+		t.Fatal("expected Sealed to report true")
+	}
+	expected := "'sha256-ypeBEsobvcr6wjGzmiPcTaeG7/gUfE5yuYB3ha/uSLs=' https://cdn.example.com"
+
+	for name, add := range map[string]func() error{
+		"AddSrc":            func() error { return scriptSrc.AddSrc("https://other.example.com/lib.js") },
+		"AddHost":           func() error { return scriptSrc.AddHost("https://other.example.com") },
+		"AddSource":         func() error { return scriptSrc.AddSource("'unsafe-eval'") },
+		"AddFromHTMLString": func() error { return scriptSrc.AddFromHTMLString("<script>b</script>", false) },
+		"AddFromHTMLFile":   func() error { return scriptSrc.AddFromHTMLFile("./tests/index.html", false) },
+		"Policy.AddFromHTMLReader": func() error {
+			policy := Policy{ScriptSrc: scriptSrc}
+			return policy.AddFromHTMLReader(strings.NewReader("<script>b</script>"), false)
+		},
+	} {
+		if err := add(); !errors.Is(err, ErrSealed) {
+			t.Errorf("%v: expected ErrSealed, got %v", name, err)
+		}
+	}
+
+	for name, add := range map[string]func(){
+		"AddInline": func() { scriptSrc.AddInline("b") },
+		"AddOther":  func() { scriptSrc.AddOther("'unsafe-eval'") },
+		"Merge":     func() { scriptSrc.Merge(&ScriptSrc{Self: true}) },
+		"Optimize":  func() { scriptSrc.Optimize() },
+	} {
+		func() {
+			defer func() {
+				if err, _ := recover().(error); !errors.Is(err, ErrSealed) {
+					t.Errorf("%v: expected a panic with ErrSealed, got %v", name, err)
+				}
+			}()
+			add()
+		}()
+	}
+
+	if got := scriptSrc.String(); got != expected {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	var unsealed ScriptSrc
+	unsealed.Merge(&scriptSrc)
+	unsealed.AddInline("b")
+	if unsealed.Sealed() || len(unsealed.Hashes) != 2 {
+		t.Errorf("expected an unsealed copy with 2 hashes, got %v", unsealed.String())
+	}
+}
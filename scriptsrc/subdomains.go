@@ -0,0 +1,69 @@
+package scriptsrc
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// wildcardHost returns the wildcard host source, such as https://*.example.com, that matches host,
+// and any other subdomain of its registrable domain, or false if host isn't a subdomain of a
+// registrable domain.
+//
+// The registrable domain is found using the public suffix list, so hosts such as
+// https://user.github.io have no wildcard, since github.io is a public suffix. Hosts with a path,
+// such as https://a.example.com/js/, have no wildcard either, since a wildcard without the path
+// would allow every path.
+func wildcardHost(host string) (string, bool) {
+	u, err := url.Parse(host)
+	if err != nil || u.Scheme == "" || u.Path != "" {
+		return "", false
+	}
+	hostname := strings.TrimPrefix(u.Hostname(), "*.")
+	if net.ParseIP(hostname) != nil {
+		return "", false
+	}
+	domain, err := publicsuffix.EffectiveTLDPlusOne(hostname)
+	if err != nil {
+		return "", false
+	}
+	if domain == u.Hostname() {
+		// This is the registrable domain itself, which *.domain doesn't match.
+		return "", false
+	}
+	wildcard := u.Scheme + "://*." + domain
+	if port := u.Port(); port != "" {
+		wildcard += ":" + port
+	}
+	return wildcard, true
+}
+
+// CollapseSubdomains replaces hosts that are subdomains of the same registrable domain, such as
+// https://a.example.com and https://b.example.com, with a single wildcard host, such as
+// https://*.example.com, when there are at least two of them.
+//
+// Hosts are only collapsed with others with the same scheme and port, and never across different
+// registrable domains, which are found using the public suffix list. Hosts with a path are never
+// collapsed. The wildcard is added in place of the first of the hosts it replaces.
+//
+// This makes the policy shorter, but allows scripts from any subdomain, so only use it if every
+// subdomain is trusted.
+func (scriptSrc *ScriptSrc) CollapseSubdomains() {
+	scriptSrc.panicIfSealed("CollapseSubdomains")
+	counts := make(map[string]int)
+	for _, host := range scriptSrc.Hosts {
+		if wildcard, ok := wildcardHost(host); ok {
+			counts[wildcard]++
+		}
+	}
+	hosts := make([]string, 0, len(scriptSrc.Hosts))
+	for _, host := range scriptSrc.Hosts {
+		if wildcard, ok := wildcardHost(host); ok && counts[wildcard] >= 2 {
+			host = wildcard
+		}
+		hosts = appendUnique(hosts, host)
+	}
+	scriptSrc.Hosts = hosts
+}
@@ -0,0 +1,60 @@
+package scriptsrc
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestWildcardHost(t *testing.T) {
+	tests := []struct {
+		host     string
+		expected string
+	}{
+		{"https://a.example.com", "https://*.example.com"},
+		{"https://a.b.example.co.uk", "https://*.example.co.uk"},
+		{"https://a.example.com:8443", "https://*.example.com:8443"},
+		{"https://*.example.com", "https://*.example.com"},
+		{"wss://a.example.com", "wss://*.example.com"},
+		{"https://example.com", ""},
+		{"https://user.github.io", ""},
+		{"https://127.0.0.1", ""},
+		{"https://localhost", ""},
+		{"a.example.com", ""},
+		{"https://a.example.com/js/", ""},
+	}
+	for _, test := range tests {
+		got, ok := wildcardHost(test.host)
+		if ok != (test.expected != "") || got != test.expected {
+			t.Errorf("expected %q for %v, got %q", test.expected, test.host, got)
+		}
+	}
+}
+
+func TestCollapseSubdomains(t *testing.T) {
+	scriptSrc := ScriptSrc{Hosts: []string{
+		"https://a.example.com",
+		"https://cdn.other.com",
+		"https://example.com",
+		"https://b.example.com",
+		"https://a.example.com:8443",
+		"https://one.github.io",
+		"https://two.github.io",
+		"https://x.y.example.com",
+		"https://c.example.com/js/",
+		"https://d.example.com/js/",
+	}}
+	scriptSrc.CollapseSubdomains()
+	expected := []string{
+		"https://*.example.com",
+		"https://cdn.other.com",
+		"https://example.com",
+		"https://a.example.com:8443",
+		"https://one.github.io",
+		"https://two.github.io",
+		"https://c.example.com/js/",
+		"https://d.example.com/js/",
+	}
+	if !slices.Equal(scriptSrc.Hosts, expected) {
+		t.Errorf("expected %v, got %v", expected, scriptSrc.Hosts)
+	}
+}
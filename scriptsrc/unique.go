@@ -0,0 +1,78 @@
+package scriptsrc
+
+// uniqueIndex indexes the values of a slice, such as ScriptSrc.Hosts, so that appending a value
+// only if it isn't already present doesn't scan the slice, which is quadratic over large sites.
+//
+// Since the slices it indexes are exported, and may be modified directly, the index is only
+// trusted while the slice has the same first element and length as when it was last updated, and
+// each value found is checked against the slice. Otherwise, the index is rebuilt. Replacing an
+// element in place, without changing the length, can't be detected, so may allow a duplicate.
+//
+// Shallow copies of a ScriptSrc copy its indexes, sharing their maps, so an index is also rebuilt,
+// into a new map, when it isn't at the address it was built at. This keeps copies independent, so
+// adding to one copy never writes to a map that another copy, maybe in another goroutine, reads.
+type uniqueIndex struct {
+	// indices maps each value to its index in the slice.
+	indices map[string]int
+	// owner is the address of the index that indices was built by, so copies can be detected.
+	owner *uniqueIndex
+	// first is the address of the first element of the slice, or nil if it was empty.
+	first *string
+	// len is the length of the slice.
+	len int
+}
+
+// firstElement returns the address of the first element of slice, or nil if it's empty.
+func firstElement(slice []string) *string {
+	if len(slice) == 0 {
+		return nil
+	}
+	return &slice[0]
+}
+
+// rebuild indexes slice, from scratch.
+//
+// A new map is always allocated, since shallow copies of a ScriptSrc share the old one.
+func (index *uniqueIndex) rebuild(slice []string) {
+	index.indices = make(map[string]int, len(slice))
+	index.owner = index
+	for i, value := range slice {
+		if _, ok := index.indices[value]; !ok {
+			index.indices[value] = i
+		}
+	}
+	index.first = firstElement(slice)
+	index.len = len(slice)
+}
+
+// contains reports whether value is in slice, which must be indexed.
+func (index *uniqueIndex) contains(slice []string, value string) bool {
+	i, ok := index.indices[value]
+	return ok && i < len(slice) && slice[i] == value
+}
+
+// appendUnique appends each value to slice, if it isn't already present, exactly as
+// [appendUnique] does, but using and updating the index.
+func (index *uniqueIndex) appendUnique(slice []string, values ...string) []string {
+	if index.owner != index || index.first != firstElement(slice) || index.len != len(slice) {
+		index.rebuild(slice)
+	}
+	for _, value := range values {
+		if index.contains(slice, value) {
+			continue
+		}
+		if _, ok := index.indices[value]; ok {
+			// The slice was reordered since it was indexed, so the index must be rebuilt to tell
+			// whether value is still present.
+			index.rebuild(slice)
+			if index.contains(slice, value) {
+				continue
+			}
+		}
+		index.indices[value] = len(slice)
+		slice = append(slice, value)
+	}
+	index.first = firstElement(slice)
+	index.len = len(slice)
+	return slice
+}
@@ -0,0 +1,75 @@
+package scriptsrc
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestUniqueIndex(t *testing.T) {
+	var index uniqueIndex
+	var slice []string
+	slice = index.appendUnique(slice, "a", "b", "a")
+	slice = index.appendUnique(slice, "b", "c")
+	if expected := []string{"a", "b", "c"}; !slices.Equal(slice, expected) {
+		t.Fatalf("expected %v, got %v", expected, slice)
+	}
+
+	// The slice is modified directly, as the exported fields may be.
+	slice = slices.DeleteFunc(slice, func(value string) bool { return value == "b" })
+	slice = index.appendUnique(slice, "b", "c")
+	if expected := []string{"a", "c", "b"}; !slices.Equal(slice, expected) {
+		t.Errorf("expected %v after deleting, got %v", expected, slice)
+	}
+	slices.Sort(slice)
+	slice = index.appendUnique(slice, "c", "d")
+	if expected := []string{"a", "b", "c", "d"}; !slices.Equal(slice, expected) {
+		t.Errorf("expected %v after sorting, got %v", expected, slice)
+	}
+	slice = append(slice, "e")
+	slice = index.appendUnique(slice, "e")
+	if expected := []string{"a", "b", "c", "d", "e"}; !slices.Equal(slice, expected) {
+		t.Errorf("expected %v after appending, got %v", expected, slice)
+	}
+	slice = index.appendUnique(nil, "a")
+	if expected := []string{"a"}; !slices.Equal(slice, expected) {
+		t.Errorf("expected %v after resetting, got %v", expected, slice)
+	}
+}
+
+func TestUniqueIndexCopy(t *testing.T) {
+	original := ScriptSrc{Hosts: make([]string, 0, 4)}
+	original.AddHost("https://a.example.com")
+	copied := original
+	copied.AddHost("https://b.example.com")
+	original.AddHost("https://b.example.com")
+	original.AddHost("https://c.example.com")
+	if expected := []string{"https://a.example.com", "https://b.example.com", "https://c.example.com"}; !slices.Equal(original.Hosts, expected) {
+		t.Errorf("expected %v, got %v", expected, original.Hosts)
+	}
+}
+
+func TestUniqueIndexIndependentCopies(t *testing.T) {
+	var original ScriptSrc
+	original.AddHost("https://a.example.com")
+	original.AddOther("'wasm-unsafe-eval'")
+	copied := original
+	copied.AddHost("https://b.example.com")
+	original.AddHost("https://c.example.com")
+	copied.AddHost("https://c.example.com")
+	original.AddHost("https://b.example.com")
+	copied.AddOther("'unsafe-eval'")
+
+	if expected := []string{"https://a.example.com", "https://c.example.com", "https://b.example.com"}; !slices.Equal(original.Hosts, expected) {
+		t.Errorf("expected original hosts %v, got %v", expected, original.Hosts)
+	}
+	if expected := []string{"https://a.example.com", "https://b.example.com", "https://c.example.com"}; !slices.Equal(copied.Hosts, expected) {
+		t.Errorf("expected copied hosts %v, got %v", expected, copied.Hosts)
+	}
+	if expected := []string{"'wasm-unsafe-eval'"}; !slices.Equal(original.Others, expected) {
+		t.Errorf("expected original others %v, got %v", expected, original.Others)
+	}
+	// Adding to the copy must not have written to the original's index.
+	if _, ok := original.othersIndex.indices["'unsafe-eval'"]; ok {
+		t.Errorf("adding to the copy modified the original's index")
+	}
+}
@@ -0,0 +1,270 @@
+package scriptsrc
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// validateSource returns an error if src contains characters that can't appear in a CSP source
+// expression, which would corrupt the directive it's in.
+func validateSource(src string) error {
+	if src == "" {
+		return errors.New("empty source")
+	}
+	for _, r := range src {
+		if unicode.IsSpace(r) || unicode.IsControl(r) || r == ';' || r == ',' {
+			return fmt.Errorf("source %q contains invalid character %q", src, r)
+		}
+	}
+	return nil
+}
+
+// hostSourcePattern matches host-source from the CSP grammar, for example https://*.example.com:443
+//
+// See https://www.w3.org/TR/CSP3/#grammardef-host-source
+var hostSourcePattern = regexp.MustCompile(
+	`^(?:[A-Za-z][A-Za-z0-9+\-.]*://)?` + // scheme-part "://"
+		`(?:\*|(?:\*\.)?[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.?)` + // host-part
+		`(?::(?:[0-9]+|\*))?` + // ":" port-part
+		`(?:/[^\s;,]*)?$`, // path-part
+)
+
+// schemeSourcePattern matches scheme-source from the CSP grammar, for example https: or blob:
+//
+// See https://www.w3.org/TR/CSP3/#grammardef-scheme-source
+var schemeSourcePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+\-.]*:$`)
+
+// validateHostSource returns an error if host isn't a valid CSP host source.
+func validateHostSource(host string) error {
+	if !hostSourcePattern.MatchString(host) {
+		return fmt.Errorf("%q is not a valid host source", host)
+	}
+	return nil
+}
+
+// Keywords are the CSP keyword sources that are meaningful in script-src, quoted as they appear in
+// a policy. It must not be modified.
+//
+// See https://www.w3.org/TR/CSP3/#grammardef-keyword-source
+var Keywords = []string{
+	"'self'",
+	"'none'",
+	"'unsafe-inline'",
+	"'unsafe-eval'",
+	"'unsafe-hashes'",
+	"'strict-dynamic'",
+	"'report-sample'",
+	"'wasm-unsafe-eval'",
+	"'inline-speculation-rules'",
+}
+
+// IsKeyword reports whether src is one of the Keywords. Keywords are case insensitive, as they are
+// for browsers, so 'SELF' is a keyword, but surrounding whitespace isn't allowed.
+func IsKeyword(src string) bool {
+	return slices.ContainsFunc(Keywords, func(keyword string) bool {
+		return strings.EqualFold(src, keyword)
+	})
+}
+
+// nonceOrHashSourcePattern matches nonce-source and hash-source from the CSP grammar, for example
+// 'nonce-abc123' or 'sha256-...'. Both base64 and URL-safe base64 are allowed, as the grammar does.
+//
+// See https://www.w3.org/TR/CSP3/#grammardef-nonce-source
+var nonceOrHashSourcePattern = regexp.MustCompile(`^'(?i:nonce|sha256|sha384|sha512)-[A-Za-z0-9+/\-_]+={0,2}'$`)
+
+// hashSourceSizes are the digest sizes, in bytes, of the algorithms allowed in CSP hash sources.
+var hashSourceSizes = map[string]int{
+	"sha256": 32,
+	"sha384": 48,
+	"sha512": 64,
+}
+
+// parseHashSource splits hash, a hash source without quotes, such as sha256-..., into its
+// algorithm, in lower case, and digest.
+//
+// Only the first "-" separates the algorithm from the digest, since URL-safe base64 digests may
+// contain "-" themselves. The digest may be standard or URL-safe base64, with or without padding,
+// as the CSP grammar allows, but must be exactly the size of the algorithm's digest.
+func parseHashSource(hash string) (string, []byte, error) {
+	alg, value, ok := strings.Cut(hash, "-")
+	alg = strings.ToLower(alg)
+	size, known := hashSourceSizes[alg]
+	if !ok || !known {
+		return "", nil, fmt.Errorf("%q doesn't start with sha256-, sha384- or sha512-", hash)
+	}
+	encoding := base64.RawStdEncoding
+	if strings.ContainsAny(value, "-_") {
+		encoding = base64.RawURLEncoding
+	}
+	digest, err := encoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return "", nil, fmt.Errorf("%q has an invalid base64 digest: %w", hash, err)
+	}
+	if len(digest) != size {
+		return "", nil, fmt.Errorf("%q has a %v byte digest, but %v digests are %v bytes", hash, len(digest), alg, size)
+	}
+	return alg, digest, nil
+}
+
+// isRecognizedSource reports whether src is a keyword, nonce, hash, scheme or host source.
+// Keywords, and the nonce and hash prefixes, are case insensitive, as they are for browsers.
+func isRecognizedSource(src string) bool {
+	return IsKeyword(src) ||
+		nonceOrHashSourcePattern.MatchString(src) ||
+		schemeSourcePattern.MatchString(src) ||
+		hostSourcePattern.MatchString(src)
+}
+
+// Validate checks that each of the Hosts and Others are valid CSP sources, returning an error
+// describing every invalid entry.
+//
+// Others are trimmed of surrounding whitespace before being checked, as they are when formatted.
+// If StrictSources is set, Others that aren't recognized sources, such as the typo 'unsafe_eval',
+// are also invalid.
+func (scriptSrc *ScriptSrc) Validate() error {
+	var errs []error
+	for _, host := range scriptSrc.Hosts {
+		if err := validateSource(host); err != nil {
+			errs = append(errs, fmt.Errorf("invalid host: %w", err))
+		}
+	}
+	for _, other := range scriptSrc.Others {
+		other = strings.TrimSpace(other)
+		if err := validateSource(other); err != nil {
+			errs = append(errs, fmt.Errorf("invalid other source: %w", err))
+		} else if scriptSrc.StrictSources && !isRecognizedSource(other) {
+			errs = append(errs, fmt.Errorf("unrecognized other source %v", other))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ErrTooLarge is returned, wrapped, by [ScriptSrc.CheckSize] when the directive exceeds MaxBytes.
+var ErrTooLarge = errors.New("script-src directive too large")
+
+// CheckSize returns an error wrapping [ErrTooLarge] if MaxBytes is positive and the formatted
+// directive, including the leading "script-src ", is larger than MaxBytes.
+func (scriptSrc *ScriptSrc) CheckSize() error {
+	if scriptSrc.MaxBytes <= 0 {
+		return nil
+	}
+	size := len("script-src ") + len(scriptSrc.String())
+	if size > scriptSrc.MaxBytes {
+		return fmt.Errorf(
+			"%w: %v bytes exceeds the limit of %v bytes (with %v and %v), consider using nonces or 'strict-dynamic' instead of hashing every script",
+			ErrTooLarge, size, scriptSrc.MaxBytes, plural(len(scriptSrc.Hashes), "hash"), plural(len(scriptSrc.Hosts), "host"),
+		)
+	}
+	return nil
+}
+
+// placeholderNonce is a nonce source with a 128 bit value, used to estimate the size of policies
+// using nonces.
+var placeholderNonce = "'nonce-" + strings.Repeat("A", 22) + "=='"
+
+// SizeAdvice returns suggestions for shrinking scriptSrc when it's larger than MaxBytes, as
+// reported by [ScriptSrc.CheckSize], with the size of the directive before and after each.
+//
+// Currently, this suggests replacing the hashes of script elements with a single nonce, and also
+// using 'strict-dynamic', under which the hosts and 'self' can be dropped. Hashes of event
+// handlers are always kept, since nonces don't apply to them. The sizes assume a 128 bit nonce.
+func (scriptSrc *ScriptSrc) SizeAdvice() []string {
+	if scriptSrc.MaxBytes <= 0 {
+		return nil
+	}
+	directiveSize := func(scriptSrc *ScriptSrc) int {
+		return len("script-src ") + len(scriptSrc.String())
+	}
+	size := directiveSize(scriptSrc)
+	if size <= scriptSrc.MaxBytes {
+		return nil
+	}
+	var replaceable, kept []string
+	for _, hash := range scriptSrc.Hashes {
+		if slices.Contains(scriptSrc.EventHandlerHashes, hash) {
+			kept = append(kept, hash)
+		} else {
+			replaceable = append(replaceable, hash)
+		}
+	}
+	if len(replaceable) == 0 {
+		return nil
+	}
+
+	nonced := *scriptSrc
+	nonced.Hashes = kept
+	nonced.Others = append(slices.Clone(scriptSrc.Others), placeholderNonce)
+	advice := []string{fmt.Sprintf(
+		"replacing the %v hashes of script elements%v with a single nonce shrinks script-src from %v to %v bytes",
+		len(replaceable), scriptSrc.labelSummary(replaceable), size, directiveSize(&nonced),
+	)}
+
+	strict := nonced
+	strict.StrictDynamic = true
+	strict.SelfMode = SelfNever
+	strict.Hosts = nil
+	if strictSize := directiveSize(&strict); strictSize < directiveSize(&nonced) {
+		advice = append(advice, fmt.Sprintf(
+			"also using 'strict-dynamic', and dropping %v, which browsers supporting it ignore, shrinks script-src from %v to %v bytes",
+			ignoredSources(scriptSrc.includesSelf(), len(scriptSrc.Hosts), 0), size, strictSize,
+		))
+	}
+	return advice
+}
+
+// labelSummary describes where hashes came from, according to their Labels, such as
+// " (12 from index.html, 3 from about.html)", listing at most the 3 most common labels. If none of
+// the hashes have labels, it returns "".
+func (scriptSrc *ScriptSrc) labelSummary(hashes []string) string {
+	counts := make(map[string]int)
+	var labels []string
+	for _, hash := range hashes {
+		label, ok := scriptSrc.Labels[hash]
+		if !ok {
+			continue
+		}
+		if counts[label] == 0 {
+			labels = append(labels, label)
+		}
+		counts[label]++
+	}
+	if len(labels) == 0 {
+		return ""
+	}
+	slices.SortStableFunc(labels, func(a, b string) int {
+		return counts[b] - counts[a]
+	})
+	parts := make([]string, 0, 3)
+	for _, label := range labels[:min(len(labels), 3)] {
+		parts = append(parts, fmt.Sprintf("%v from %v", counts[label], label))
+	}
+	if len(labels) > 3 {
+		parts = append(parts, "...")
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+// ErrNeedsUnsafeInline is returned, wrapped, by [ScriptSrc.CheckStrict] when the policy can't allow
+// every script found without 'unsafe-inline'.
+var ErrNeedsUnsafeInline = errors.New("script-src can't allow every script without 'unsafe-inline'")
+
+// CheckStrict returns an error wrapping [ErrNeedsUnsafeInline] if the collected sources can't form
+// a working policy without 'unsafe-inline'.
+//
+// Currently, this is when event handlers were hashed, but 'unsafe-hashes' isn't in Others, since
+// browsers never allow event handlers by hash without it. Either add 'unsafe-hashes', or better,
+// move the event handlers into scripts.
+func (scriptSrc *ScriptSrc) CheckStrict() error {
+	if len(scriptSrc.EventHandlerHashes) > 0 && !hasOther("'unsafe-hashes'")(scriptSrc) {
+		return fmt.Errorf(
+			"%w: %v event handlers were hashed, but 'unsafe-hashes' isn't included, so browsers will block them",
+			ErrNeedsUnsafeInline, len(scriptSrc.EventHandlerHashes),
+		)
+	}
+	return nil
+}
@@ -0,0 +1,291 @@
+package scriptsrc
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	valid := []string{"'unsafe-eval'", " 'wasm-unsafe-eval'\n", "https://example.com", "blob:"}
+	for _, other := range valid {
+		scriptSrc := ScriptSrc{Others: []string{other}}
+		if err := scriptSrc.Validate(); err != nil {
+			t.Errorf("unexpected error for %q: %v", other, err)
+		}
+	}
+
+	invalid := []string{"", "  ", "'self' 'unsafe-eval'", "'unsafe-eval';", "a,b", "a\nb"}
+	for _, other := range invalid {
+		scriptSrc := ScriptSrc{Others: []string{other}}
+		if err := scriptSrc.Validate(); err == nil {
+			t.Errorf("expected an error for %q", other)
+		}
+	}
+
+	scriptSrc := ScriptSrc{Hosts: []string{"https://example.com;"}}
+	if err := scriptSrc.Validate(); err == nil {
+		t.Errorf("expected an error for an invalid host")
+	}
+}
+
+func TestOthersTrimmed(t *testing.T) {
+	scriptSrc := ScriptSrc{Self: true, Others: []string{" 'unsafe-eval'\n", "  "}}
+	if got := scriptSrc.String(); got != "'self' 'unsafe-eval'" {
+		t.Errorf("expected 'self' 'unsafe-eval', got %q", got)
+	}
+}
+
+func TestCheckSize(t *testing.T) {
+	scriptSrc := ScriptSrc{Self: true}
+	if err := scriptSrc.CheckSize(); err != nil {
+		t.Errorf("unexpected error without MaxBytes: %v", err)
+	}
+	scriptSrc.MaxBytes = len("script-src 'self'")
+	if err := scriptSrc.CheckSize(); err != nil {
+		t.Errorf("unexpected error at exactly MaxBytes: %v", err)
+	}
+	scriptSrc.MaxBytes--
+	if err := scriptSrc.CheckSize(); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("expected ErrTooLarge, got %v", err)
+	}
+}
+
+func TestCheckStrict(t *testing.T) {
+	scriptSrc, err := ScriptSrcFromHTMLFile("./tests/event-handlers.html", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := scriptSrc.CheckStrict(); err != nil {
+		t.Errorf("unexpected error without event handlers: %v", err)
+	}
+
+	scriptSrc, err = ScriptSrcFromHTMLFile("./tests/event-handlers.html", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scriptSrc.EventHandlerHashes) == 0 {
+		t.Fatal("expected event handler hashes")
+	}
+	if err := scriptSrc.CheckStrict(); !errors.Is(err, ErrNeedsUnsafeInline) {
+		t.Errorf("expected ErrNeedsUnsafeInline, got %v", err)
+	}
+	scriptSrc.AddOther("'unsafe-hashes'")
+	if err := scriptSrc.CheckStrict(); err != nil {
+		t.Errorf("unexpected error with 'unsafe-hashes': %v", err)
+	}
+	// Keywords are case-insensitive.
+	scriptSrc.Others = []string{" 'UNSAFE-Hashes' "}
+	if err := scriptSrc.CheckStrict(); err != nil {
+		t.Errorf("unexpected error with mixed case 'unsafe-hashes': %v", err)
+	}
+}
+
+func TestStrictSources(t *testing.T) {
+	recognized := []string{
+		"'unsafe-eval'",
+		"'UNSAFE-EVAL'",
+		"'nonce-abc123=='",
+		"'sha256-ypeBEsobvcr6wjGzmiPcTaeG7/gUfE5yuYB3ha/uSLs='",
+		"'sha256-ypeBEsobvcr6wjGzmiPcTaeG7_gUfE5yuYB3ha_uSLs='",
+		"data:",
+		"https://example.com",
+	}
+	for _, other := range recognized {
+		scriptSrc := ScriptSrc{Others: []string{other}, StrictSources: true}
+		if err := scriptSrc.Validate(); err != nil {
+			t.Errorf("unexpected error for %q: %v", other, err)
+		}
+	}
+
+	unrecognized := []string{"'unsafe_eval'", "'sha512/256-ypeBEsobvcr6wjGzmiPcTaeG7/gUfE5yuYB3ha/uSLs='", "'nonce-'", "'nonce-a*b'", "'self"}
+	for _, other := range unrecognized {
+		scriptSrc := ScriptSrc{Others: []string{other}}
+		if err := scriptSrc.Validate(); err != nil {
+			t.Errorf("unexpected error for %q without StrictSources: %v", other, err)
+		}
+		scriptSrc.StrictSources = true
+		if err := scriptSrc.Validate(); err == nil {
+			t.Errorf("expected an error for %q", other)
+		}
+	}
+
+	var scriptSrc ScriptSrc
+	if err := scriptSrc.AddSource("'unsafe_eval'"); err != nil {
+		t.Errorf("unexpected error without StrictSources: %v", err)
+	}
+	if len(scriptSrc.Warnings) != 1 {
+		t.Errorf("expected a warning for an unrecognized source, got %q", scriptSrc.Warnings)
+	}
+	scriptSrc.StrictSources = true
+	if err := scriptSrc.AddSource("'unsafe-evel'"); err == nil {
+		t.Errorf("expected an error with StrictSources")
+	}
+}
+
+func TestIsKeyword(t *testing.T) {
+	tests := map[string]bool{
+		"'self'":             true,
+		"'SELF'":             true,
+		"'none'":             true,
+		"'wasm-unsafe-eval'": true,
+		"self":               false,
+		"'unsafe_eval'":      false,
+		" 'self'":            false,
+		"'sha256-abc='":      false,
+	}
+	for src, expected := range tests {
+		if got := IsKeyword(src); got != expected {
+			t.Errorf("IsKeyword(%q): expected %v, got %v", src, expected, got)
+		}
+	}
+}
+
+func TestParseHashSource(t *testing.T) {
+	valid := []struct {
+		hash string
+		alg  string
+	}{
+		{"sha512-X+aeR+9dEmqY9SqucXOUgHMKCI8yYCIBSgAOUxQ41fJBfPlM2nLA24g8XIxq1XJNuU+7YcvnrSkKoL5u4QVj3w==", "sha512"},
+		{"sha512-X-aeR-9dEmqY9SqucXOUgHMKCI8yYCIBSgAOUxQ41fJBfPlM2nLA24g8XIxq1XJNuU-7YcvnrSkKoL5u4QVj3w==", "sha512"},
+		{"sha512-X-aeR-9dEmqY9SqucXOUgHMKCI8yYCIBSgAOUxQ41fJBfPlM2nLA24g8XIxq1XJNuU-7YcvnrSkKoL5u4QVj3w", "sha512"},
+		{"sha256-ypeBEsobvcr6wjGzmiPcTaeG7/gUfE5yuYB3ha/uSLs=", "sha256"},
+		{"SHA256-ypeBEsobvcr6wjGzmiPcTaeG7_gUfE5yuYB3ha_uSLs=", "sha256"},
+		{"sha384-oqVuAfXRKap7fdgcCY5uykM6+R9GqQ8K/uxy9rx7HNQlGYl1kPzQho1wx4JwY8wC", "sha384"},
+	}
+	var std []byte
+	for _, test := range valid {
+		alg, digest, err := parseHashSource(test.hash)
+		if err != nil {
+			t.Errorf("unexpected error for %v: %v", test.hash, err)
+			continue
+		}
+		if alg != test.alg {
+			t.Errorf("expected algorithm %v for %v, got %v", test.alg, test.hash, alg)
+		}
+		if std == nil {
+			std = digest
+		} else if alg == "sha512" && !slices.Equal(digest, std) {
+			t.Errorf("expected the URL-safe digest of %v to match the standard one", test.hash)
+		}
+	}
+
+	for _, hash := range []string{
+		"sha512",
+		"sha1-ypeBEsobvcr6wjGzmiPcTaeG7/gUfE5yuYB3ha/uSLs=",
+		"sha512/256-ypeBEsobvcr6wjGzmiPcTaeG7/gUfE5yuYB3ha/uSLs=",
+		"sha256-ypeBEsobvcr6wjGzmiPcTaeG7/gUfE5yuYB3ha/uSL",
+		"sha512-ypeBEsobvcr6wjGzmiPcTaeG7/gUfE5yuYB3ha/uSLs=",
+		"sha256-ypeBEsobvcr6wjGzmiPcTaeG7/gUfE5yuYB3ha_uSLs=",
+		"sha256-ypeBEsobvcr6wjGzmiPcTaeG7*gUfE5yuYB3ha/uSLs=",
+	} {
+		if _, _, err := parseHashSource(hash); err == nil {
+			t.Errorf("expected an error for %v", hash)
+		}
+	}
+}
+
+func TestAddHost(t *testing.T) {
+	valid := []string{
+		"https://cdn.example.com",
+		"https://cdn.example.com:8443",
+		"https://*.example.com",
+		"https://cdn.example.com:*",
+		"https://cdn.example.com/scripts/",
+		"cdn.example.com",
+		"*",
+		"http://localhost:8080",
+		"wss://example.com",
+	}
+	var scriptSrc ScriptSrc
+	for _, host := range valid {
+		if err := scriptSrc.AddHost(host); err != nil {
+			t.Errorf("unexpected error adding %v: %v", host, err)
+		}
+	}
+	scriptSrc.AddHost(valid[0])
+	if !slices.Equal(scriptSrc.Hosts, valid) {
+		t.Errorf("expected hosts %v, got %v", valid, scriptSrc.Hosts)
+	}
+
+	invalid := []string{
+		"",
+		"https://",
+		"https://cdn example.com",
+		"https://cdn.example.com;",
+		"https://cdn.*.example.com",
+		"https://[::1]",
+		"https://cdn.example.com:port",
+		"'self'",
+	}
+	for _, host := range invalid {
+		if err := scriptSrc.AddHost(host); err == nil {
+			t.Errorf("expected an error adding %q", host)
+		}
+	}
+}
+
+func TestAddSource(t *testing.T) {
+	var scriptSrc ScriptSrc
+	for _, src := range []string{
+		"'self'",
+		"'strict-dynamic'",
+		"'sha256-ypeBEsobvcr6wjGzmiPcTaeG7/gUfE5yuYB3ha/uSLs='",
+		"https://www.google-analytics.com",
+		" https://www.google-analytics.com ",
+		"'unsafe-eval'",
+		"blob:",
+	} {
+		if err := scriptSrc.AddSource(src); err != nil {
+			t.Errorf("unexpected error adding %q: %v", src, err)
+		}
+	}
+	expected := "'self' 'strict-dynamic' 'sha256-ypeBEsobvcr6wjGzmiPcTaeG7/gUfE5yuYB3ha/uSLs=' https://www.google-analytics.com 'unsafe-eval' blob:"
+	if got := scriptSrc.String(); got != expected {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	for _, src := range []string{"'sha256-abc", "'sha256-abc='", "'unsafe eval'", "https://cdn example.com", ""} {
+		if err := scriptSrc.AddSource(src); err == nil {
+			t.Errorf("expected an error adding %q", src)
+		}
+	}
+}
+
+func TestSizeAdvice(t *testing.T) {
+	scriptSrc := ScriptSrc{
+		Self:               true,
+		Hosts:              []string{"https://cdn.example.com"},
+		Hashes:             []string{"sha256-a", "sha256-b", "sha256-c"},
+		EventHandlerHashes: []string{"sha256-c"},
+		Labels:             map[string]string{"sha256-a": "index.html", "sha256-b": "index.html"},
+	}
+	if advice := scriptSrc.SizeAdvice(); advice != nil {
+		t.Errorf("unexpected advice without MaxBytes: %q", advice)
+	}
+	size := len("script-src ") + len(scriptSrc.String())
+	scriptSrc.MaxBytes = size
+	if advice := scriptSrc.SizeAdvice(); advice != nil {
+		t.Errorf("unexpected advice at exactly MaxBytes: %q", advice)
+	}
+
+	scriptSrc.MaxBytes = 10
+	nonced := len("script-src 'self' https://cdn.example.com 'sha256-c' 'nonce-AAAAAAAAAAAAAAAAAAAAAA=='")
+	strict := len("script-src 'strict-dynamic' 'sha256-c' 'nonce-AAAAAAAAAAAAAAAAAAAAAA=='")
+	expected := []string{
+		fmt.Sprintf("replacing the 2 hashes of script elements (2 from index.html) with a single nonce shrinks script-src from %v to %v bytes", size, nonced),
+		fmt.Sprintf("also using 'strict-dynamic', and dropping 'self' and 1 host, which browsers supporting it ignore, shrinks script-src from %v to %v bytes", size, strict),
+	}
+	if advice := scriptSrc.SizeAdvice(); !slices.Equal(advice, expected) {
+		t.Errorf("expected %q, got %q", expected, advice)
+	}
+	if len(scriptSrc.Hashes) != 3 || len(scriptSrc.Hosts) != 1 || len(scriptSrc.Others) != 0 || scriptSrc.StrictDynamic {
+		t.Errorf("SizeAdvice modified the policy: %v", scriptSrc.String())
+	}
+
+	onlyHandlers := ScriptSrc{Hashes: []string{"sha256-c"}, EventHandlerHashes: []string{"sha256-c"}, MaxBytes: 10}
+	if advice := onlyHandlers.SizeAdvice(); advice != nil {
+		t.Errorf("unexpected advice with only event handler hashes: %q", advice)
+	}
+}
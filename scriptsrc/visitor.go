@@ -0,0 +1,50 @@
+package scriptsrc
+
+import "golang.org/x/net/html"
+
+// Visitor observes each script found by [ScriptSrc.AddFromHTML], before its sources are added.
+//
+// If a method returns an error, AddFromHTML stops and returns it, so visitors can also reject
+// scripts.
+type Visitor interface {
+	// VisitInlineScript is called for each script tag without a src attribute.
+	VisitInlineScript(n *html.Node, content string) error
+
+	// VisitExternalScript is called for each script tag with a src attribute.
+	VisitExternalScript(n *html.Node, src string) error
+
+	// VisitEventHandler is called for each event handler attribute, when event handlers are
+	// included.
+	VisitEventHandler(n *html.Node, attr html.Attribute) error
+}
+
+// VisitorFuncs is a Visitor that calls each of its functions that are set.
+type VisitorFuncs struct {
+	InlineScript   func(n *html.Node, content string) error
+	ExternalScript func(n *html.Node, src string) error
+	EventHandler   func(n *html.Node, attr html.Attribute) error
+}
+
+// VisitInlineScript calls visitor.InlineScript, if set.
+func (visitor VisitorFuncs) VisitInlineScript(n *html.Node, content string) error {
+	if visitor.InlineScript == nil {
+		return nil
+	}
+	return visitor.InlineScript(n, content)
+}
+
+// VisitExternalScript calls visitor.ExternalScript, if set.
+func (visitor VisitorFuncs) VisitExternalScript(n *html.Node, src string) error {
+	if visitor.ExternalScript == nil {
+		return nil
+	}
+	return visitor.ExternalScript(n, src)
+}
+
+// VisitEventHandler calls visitor.EventHandler, if set.
+func (visitor VisitorFuncs) VisitEventHandler(n *html.Node, attr html.Attribute) error {
+	if visitor.EventHandler == nil {
+		return nil
+	}
+	return visitor.EventHandler(n, attr)
+}
@@ -0,0 +1,52 @@
+package scriptsrc
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestVisitor(t *testing.T) {
+	var visited []string
+	scriptSrc := ScriptSrc{Visitor: VisitorFuncs{
+		InlineScript: func(n *html.Node, content string) error {
+			visited = append(visited, "inline")
+			return nil
+		},
+		ExternalScript: func(n *html.Node, src string) error {
+			visited = append(visited, "external "+src)
+			return nil
+		},
+		EventHandler: func(n *html.Node, attr html.Attribute) error {
+			visited = append(visited, "handler "+attr.Key)
+			return nil
+		},
+	}}
+	err := scriptSrc.AddFromHTMLFile("./tests/index.html", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"external https://challenges.cloudflare.com/turnstile/v0/api.js",
+		"inline",
+		"handler onclick",
+		"inline",
+		"external https://accounts.mevitae.com/handover-token.js",
+	}
+	if !slices.Equal(visited, expected) {
+		t.Errorf("expected visits %q, got %q", expected, visited)
+	}
+
+	rejected := errors.New("rejected")
+	scriptSrc = ScriptSrc{Visitor: VisitorFuncs{
+		EventHandler: func(n *html.Node, attr html.Attribute) error {
+			return rejected
+		},
+	}}
+	err = scriptSrc.AddFromHTMLFile("./tests/index.html", true)
+	if !errors.Is(err, rejected) {
+		t.Errorf("expected the visitor's error, got %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package scriptsrchttp
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/JOT85/script-src-generator/scriptsrc"
+)
+
+// DefaultTimeout is the timeout used when fetching pages and scripts without a custom client.
+const DefaultTimeout = 30 * time.Second
+
+// AddFromURL fetches the HTML document at url, and then calls scriptSrc.AddFromHTML with the result.
+//
+// # Security
+//
+// The fetched HTML must be trusted! Fetching your own deployed page is useful for checking that a
+// policy matches what is actually served, but if the served page could contain injected content (or
+// the response could be tampered with), the generated policy will allow the injected scripts. See
+// the scriptsrc package documentation for more details.
+func AddFromURL(scriptSrc *scriptsrc.ScriptSrc, url string, includeEventHandlers bool) error {
+	return AddFromURLWithClient(nil, scriptSrc, url, includeEventHandlers)
+}
+
+// AddFromURLWithClient is like AddFromURL, but fetches url using client, which can be used to
+// configure timeouts, proxies and TLS settings.
+//
+// If client is nil, a client with a timeout of [DefaultTimeout] is used.
+func AddFromURLWithClient(client *http.Client, scriptSrc *scriptsrc.ScriptSrc, url string, includeEventHandlers bool) error {
+	// Check before fetching, rather than leaving it to AddFromHTMLReader.
+	if scriptSrc.Sealed() {
+		return fmt.Errorf("%w: can't call scriptsrchttp.AddFromURLWithClient", scriptsrc.ErrSealed)
+	}
+	if client == nil {
+		client = &http.Client{Timeout: DefaultTimeout}
+	}
+	res, err := client.Get(url)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %v: %w", url, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch %v: %v", url, res.Status)
+	}
+	err = scriptSrc.AddFromHTMLReader(res.Body, includeEventHandlers)
+	if err != nil {
+		return fmt.Errorf("failed to process %v: %w", url, err)
+	}
+	return nil
+}
+
+// ScriptSrcFromURL generates the script-src required to load the HTML document served at url.
+//
+// The fetched HTML must be trusted! See [AddFromURL] and the scriptsrc package documentation.
+func ScriptSrcFromURL(url string, includeEventHandlers bool) (*scriptsrc.ScriptSrc, error) {
+	return ScriptSrcFromURLWithClient(nil, url, includeEventHandlers)
+}
+
+// ScriptSrcFromURLWithClient is like ScriptSrcFromURL, but fetches url using client. See
+// [AddFromURLWithClient].
+func ScriptSrcFromURLWithClient(client *http.Client, url string, includeEventHandlers bool) (*scriptsrc.ScriptSrc, error) {
+	scriptSrc := &scriptsrc.ScriptSrc{}
+	err := AddFromURLWithClient(client, scriptSrc, url, includeEventHandlers)
+	if err != nil {
+		return nil, err
+	}
+	return scriptSrc, nil
+}
+
+// ExternalScriptContent returns a [scriptsrc.ExternalScriptContentFunc] that fetches the content of
+// external scripts using client. Relative srcs can't be fetched, and return an error.
+//
+// If client is nil, a client with a timeout of [DefaultTimeout] is used.
+//
+// The fetched scripts are trusted and hashed exactly as they are served when this runs, so only use
+// this for scripts you control, or that are otherwise versioned and immutable.
+func ExternalScriptContent(client *http.Client) scriptsrc.ExternalScriptContentFunc {
+	if client == nil {
+		client = &http.Client{Timeout: DefaultTimeout}
+	}
+	return func(src string) (string, error) {
+		res, err := client.Get(src)
+		if err != nil {
+			return "", err
+		}
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("unexpected status %v", res.Status)
+		}
+		content, err := io.ReadAll(res.Body)
+		if err != nil {
+			return "", err
+		}
+		return string(content), nil
+	}
+}
@@ -0,0 +1,83 @@
+package scriptsrchttp
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/JOT85/script-src-generator/scriptsrc"
+)
+
+func TestScriptSrcFromURL(t *testing.T) {
+	server := httptest.NewServer(http.FileServer(http.Dir("../scriptsrc/tests")))
+	defer server.Close()
+
+	scriptSrc, err := ScriptSrcFromURL(server.URL+"/just-self.html", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := scriptSrc.String(); got != "'self'" {
+		t.Errorf("expected 'self', got %v", got)
+	}
+
+	_, err = ScriptSrcFromURL(server.URL+"/does-not-exist.html", true)
+	if err == nil {
+		t.Errorf("expected an error for a missing page")
+	}
+
+	scriptSrc = &scriptsrc.ScriptSrc{}
+	scriptSrc.Seal()
+	if err := AddFromURL(scriptSrc, server.URL+"/just-self.html", true); !errors.Is(err, scriptsrc.ErrSealed) {
+		t.Errorf("expected a sealed error, got %v", err)
+	}
+}
+
+func TestScriptSrcFromURLWithClient(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.FileServer(http.Dir("../scriptsrc/tests")))
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	// The default client doesn't trust the test server's certificate.
+	_, err := ScriptSrcFromURL(server.URL+"/just-self.html", true)
+	if err == nil {
+		t.Errorf("expected an error fetching from an untrusted server")
+	}
+
+	scriptSrc, err := ScriptSrcFromURLWithClient(server.Client(), server.URL+"/just-self.html", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := scriptSrc.String(); got != "'self'" {
+		t.Errorf("expected 'self', got %v", got)
+	}
+}
+
+func TestExternalScriptContent(t *testing.T) {
+	// Insecure srcs are ignored, so the scripts must be served over https.
+	server := httptest.NewUnstartedServer(http.FileServer(http.Dir("../scriptsrc/tests/external")))
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	scriptSrc := scriptsrc.ScriptSrc{
+		ExternalScripts:       scriptsrc.ExternalHashes,
+		ExternalScriptContent: ExternalScriptContent(server.Client()),
+	}
+	err := scriptSrc.AddFromHTMLString(`<script src="`+server.URL+`/external.js"></script>`, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const hash = "'sha512-5zvqfxZcoHE5KMnx82Ak1BojUw68qW3tFc7ZrQqf4HBIWAEngEjRHZT2YQvxBHrwYIx37iRlY8sxp0tJ0MoRqA=='"
+	if got := scriptSrc.String(); got != hash {
+		t.Errorf("expected %v, got %v", hash, got)
+	}
+
+	err = scriptSrc.AddFromHTMLString(`<script src="`+server.URL+`/does-not-exist.js"></script>`, false)
+	if err == nil {
+		t.Errorf("expected an error for a missing script")
+	}
+}
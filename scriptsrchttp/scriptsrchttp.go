@@ -0,0 +1,58 @@
+// Package scriptsrchttp provides net/http helpers for the [scriptsrc] package: fetching pages and
+// external scripts to generate script-src policies from, and serving the generated policies.
+//
+// This is kept separate from the scriptsrc package, so that the core API doesn't depend on
+// net/http or make network requests.
+package scriptsrchttp
+
+import (
+	"net/http"
+
+	"github.com/JOT85/script-src-generator/scriptsrc"
+)
+
+const (
+	// HeaderName is the name of the enforced Content Security Policy header.
+	HeaderName = "Content-Security-Policy"
+
+	// ReportOnlyHeaderName is the name of the report only Content Security Policy header.
+	ReportOnlyHeaderName = "Content-Security-Policy-Report-Only"
+)
+
+// HeaderValue returns the Content-Security-Policy header value for policy.
+//
+// For example: "script-src 'self' https://challenges.cloudflare.com"
+func HeaderValue(policy *scriptsrc.ScriptSrc) string {
+	return "script-src " + policy.String()
+}
+
+// SetHeader sets the Content-Security-Policy header in h to the script-src directive from policy.
+//
+// If reportOnly, the Content-Security-Policy-Report-Only header is set instead.
+func SetHeader(h http.Header, policy *scriptsrc.ScriptSrc, reportOnly bool) {
+	if reportOnly {
+		h.Set(ReportOnlyHeaderName, HeaderValue(policy))
+	} else {
+		h.Set(HeaderName, HeaderValue(policy))
+	}
+}
+
+// Middleware returns middleware that sets the Content-Security-Policy header (or
+// Content-Security-Policy-Report-Only if reportOnly) on every response, before calling the
+// wrapped handler.
+//
+// The header value is generated once, when Middleware is called, so policy must not be modified
+// afterwards.
+func Middleware(policy *scriptsrc.ScriptSrc, reportOnly bool) func(http.Handler) http.Handler {
+	name := HeaderName
+	if reportOnly {
+		name = ReportOnlyHeaderName
+	}
+	value := HeaderValue(policy)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(name, value)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
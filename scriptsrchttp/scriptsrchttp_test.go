@@ -0,0 +1,50 @@
+package scriptsrchttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/JOT85/script-src-generator/scriptsrc"
+)
+
+func TestSetHeader(t *testing.T) {
+	policy := &scriptsrc.ScriptSrc{Self: true, Hosts: []string{"https://example.com"}}
+	expected := "script-src 'self' https://example.com"
+
+	h := http.Header{}
+	SetHeader(h, policy, false)
+	if got := h.Get(HeaderName); got != expected {
+		t.Errorf("expected %v header %v, got %v", HeaderName, expected, got)
+	}
+	if got := h.Get(ReportOnlyHeaderName); got != "" {
+		t.Errorf("unexpected %v header: %v", ReportOnlyHeaderName, got)
+	}
+
+	h = http.Header{}
+	SetHeader(h, policy, true)
+	if got := h.Get(ReportOnlyHeaderName); got != expected {
+		t.Errorf("expected %v header %v, got %v", ReportOnlyHeaderName, expected, got)
+	}
+	if got := h.Get(HeaderName); got != "" {
+		t.Errorf("unexpected %v header: %v", HeaderName, got)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	policy := &scriptsrc.ScriptSrc{Self: true}
+	handler := Middleware(policy, false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("Hello"))
+	}))
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	res, err := server.Client().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if got := res.Header.Get(HeaderName); got != "script-src 'self'" {
+		t.Errorf("expected %v header script-src 'self', got %v", HeaderName, got)
+	}
+}
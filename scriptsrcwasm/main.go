@@ -0,0 +1,70 @@
+//go:build js && wasm
+
+// Command scriptsrcwasm exposes the [scriptsrc] package to JavaScript, so policies can be
+// generated client-side, such as in a web-based tool or browser dev tools.
+//
+// Build it with:
+//
+//	GOOS=js GOARCH=wasm go build -o scriptsrc.wasm ./scriptsrcwasm
+//
+// and load it with the wasm_exec.js support script from $(go env GOROOT)/lib/wasm (or misc/wasm
+// before Go 1.24). Once running, it defines a global generateFromHTMLString function:
+//
+//	const go = new Go();
+//	const { instance } = await WebAssembly.instantiateStreaming(fetch("scriptsrc.wasm"), go.importObject);
+//	go.run(instance);
+//
+//	const result = generateFromHTMLString("<script>console.log(1)</script>", { eventHandlers: true, sha256: true });
+//	if (result.error) {
+//	    console.error(result.error);
+//	} else {
+//	    console.log("script-src " + result.scriptSrc, result.warnings);
+//	}
+//
+// The HTML must be trusted, exactly as it must be for the scriptsrc package.
+package main
+
+import (
+	"strings"
+	"syscall/js"
+
+	"github.com/JOT85/script-src-generator/scriptsrc"
+)
+
+// generateFromHTMLString implements the JavaScript generateFromHTMLString(html, options) function.
+//
+// options is an optional object, whose eventHandlers property includes event handlers, and whose
+// sha256 property hashes with SHA-256 instead of SHA-512. The result is an object with either an
+// error property, or the scriptSrc value and any warnings.
+func generateFromHTMLString(this js.Value, args []js.Value) any {
+	if len(args) == 0 || args[0].Type() != js.TypeString {
+		return map[string]any{"error": "generateFromHTMLString expected an HTML string"}
+	}
+	includeEventHandlers := false
+	alg := scriptsrc.Sha512
+	if len(args) > 1 && args[1].Type() == js.TypeObject {
+		includeEventHandlers = args[1].Get("eventHandlers").Truthy()
+		if args[1].Get("sha256").Truthy() {
+			alg = scriptsrc.Sha256
+		}
+	}
+	scriptSrc := scriptsrc.New(scriptsrc.WithHashAlgorithm(alg))
+	err := scriptSrc.AddFromHTMLReader(strings.NewReader(args[0].String()), includeEventHandlers)
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	warnings := make([]any, len(scriptSrc.Warnings))
+	for i, warning := range scriptSrc.Warnings {
+		warnings[i] = warning
+	}
+	return map[string]any{
+		"scriptSrc": scriptSrc.String(),
+		"warnings":  warnings,
+	}
+}
+
+func main() {
+	js.Global().Set("generateFromHTMLString", js.FuncOf(generateFromHTMLString))
+	// Keep running, so the function can be called.
+	select {}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+	"time"
+
+	"github.com/JOT85/script-src-generator/scriptsrc"
+)
+
+// templateData is what CSP templates are executed with.
+type templateData struct {
+	// ScriptSrc is embedded, so {{ .ScriptSrc }} formats the directive value, and its other fields,
+	// such as {{ .Hashes }}, are available directly.
+	*scriptsrc.ScriptSrc
+
+	// Files are the files and URLs processed, in the order they were given.
+	Files []string
+
+	// GeneratedAt is when the output was generated.
+	GeneratedAt time.Time
+
+	// Version is the version of this tool.
+	Version string
+}
+
+// parseTemplateFile parses the template file at path. If path is a directory, every file in it is
+// parsed as a set of templates with [template.ParseGlob], and name must be given to select which
+// to execute.
+//
+// If name is given, the template with that name is returned, which may be any of the files in the
+// set, or a template defined within them with {{ define }}.
+func parseTemplateFile(path, name string) (*template.Template, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	var tmpl *template.Template
+	if info.IsDir() {
+		if name == "" {
+			return nil, fmt.Errorf("--template-name is required when the template %v is a directory", path)
+		}
+		tmpl, err = template.ParseGlob(filepath.Join(path, "*"))
+	} else {
+		tmpl, err = template.ParseFiles(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return lookupTemplate(tmpl, name)
+}
+
+// lookupTemplate returns the template associated with tmpl named name, or tmpl itself if name is
+// empty.
+func lookupTemplate(tmpl *template.Template, name string) (*template.Template, error) {
+	if name == "" {
+		return tmpl, nil
+	}
+	named := tmpl.Lookup(name)
+	if named == nil {
+		return nil, fmt.Errorf("no template named %v, expected one of: %v", name, tmpl.DefinedTemplates())
+	}
+	return named, nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/JOT85/script-src-generator/scriptsrc"
+)
+
+func TestParseTemplateFileDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"header.tmpl": `{{/* Comments don't appear in the output. */}}Content-Security-Policy: {{ template "value" . }}`,
+		"nginx.tmpl":  `add_header Content-Security-Policy "{{ template "value" . }}" always;`,
+		"value.tmpl":  `{{ define "value" }}script-src {{ .ScriptSrc }}{{ end }}`,
+	}
+	for name, content := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	data := struct{ ScriptSrc string }{"'self'"}
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"header.tmpl", "Content-Security-Policy: script-src 'self'"},
+		{"nginx.tmpl", `add_header Content-Security-Policy "script-src 'self'" always;`},
+		{"value", "script-src 'self'"},
+	}
+	for _, test := range tests {
+		tmpl, err := parseTemplateFile(dir, test.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var output strings.Builder
+		err = tmpl.Execute(&output, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if output.String() != test.expected {
+			t.Errorf("expected %q for %v, got %q", test.expected, test.name, output.String())
+		}
+	}
+
+	if _, err := parseTemplateFile(dir, ""); err == nil {
+		t.Errorf("expected an error for a directory without a template name")
+	}
+	if _, err := parseTemplateFile(dir, "missing.tmpl"); err == nil {
+		t.Errorf("expected an error for a missing template name")
+	}
+}
+
+func TestTemplateData(t *testing.T) {
+	tmpl, err := template.New("test").Parse(`{{ .ScriptSrc }} {{ len .Hashes }} {{ .Files }} {{ .Version }} {{ .GeneratedAt.Year }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output strings.Builder
+	err = tmpl.Execute(&output, templateData{
+		ScriptSrc:   &scriptsrc.ScriptSrc{Self: true},
+		Files:       []string{"index.html"},
+		GeneratedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Version:     "v1.0.0",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "'self' 0 [index.html] v1.0.0 2024"
+	if output.String() != expected {
+		t.Errorf("expected %q, got %q", expected, output.String())
+	}
+}
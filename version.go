@@ -0,0 +1,31 @@
+package main
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// version can be set at build time with:
+//
+//	go build -ldflags "-X main.version=v1.2.3"
+//
+// Otherwise, the module version from the build info is used.
+var version = ""
+
+// getVersion returns the version of this build, or "(devel)" if it isn't known.
+//
+// The scriptsrc package is part of the same module, so always has the same version.
+func getVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
+
+// versionString returns the full version information printed by --version.
+func versionString() string {
+	return "script-src-generator " + getVersion() + " (" + runtime.Version() + " " + runtime.GOOS + "/" + runtime.GOARCH + ")"
+}